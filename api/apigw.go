@@ -3,6 +3,8 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"io"
 	"log"
 	"net/http"
 	"net/url"
@@ -15,6 +17,30 @@ import (
 	"github.com/gorilla/mux"
 )
 
+// upstreamError is a response of a service with an unexpected status.
+type upstreamError struct {
+	Status  int    // status code of the service response
+	Message string // body of the service response
+}
+
+func (e *upstreamError) Error() string {
+	return e.Message
+}
+
+// checkStatus returns an upstreamError if the service response does not
+// have the expected status.
+func checkStatus(resp *http.Response, want int) error {
+	if resp.StatusCode == want {
+		return nil
+	}
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+	msg := strings.TrimSpace(string(b))
+	if msg == "" {
+		msg = http.StatusText(resp.StatusCode)
+	}
+	return &upstreamError{Status: resp.StatusCode, Message: msg}
+}
+
 // RequestResult stores the result of the request and the error.
 type RequestResult struct {
 	Data interface{}
@@ -23,26 +49,140 @@ type RequestResult struct {
 
 // Get the list of news.
 type NewsListResponse struct {
-	Posts []NewsShortDetailed `json:"posts"`
+	Posts      []NewsShortDetailed `json:"posts"`
+	Pagination Pagination          `json:"pagination"`
+}
+
+// Pagination contains the position of a news list page.
+type Pagination struct {
+	TotalPages  int
+	CurrentPage int // 0 for a page selected by a cursor
+	PageSize    int
+	TotalItems  int
+	NextCursor  string // cursor of the next page, empty on the last one
+	PrevCursor  string // cursor of the previous page, empty on the first one
+}
+
+// NewsPage is a page of the news list with links to the neighbouring pages.
+type NewsPage struct {
+	News        []NewsShortDetailed `json:"news"`
+	TotalPages  int                 `json:"total_pages"`
+	CurrentPage int                 `json:"current_page"`
+	PageSize    int                 `json:"page_size"`
+	TotalItems  int                 `json:"total_items"`
+	NextCursor  string              `json:"next_cursor,omitempty"`
+	PrevCursor  string              `json:"prev_cursor,omitempty"`
+	Next        string              `json:"next,omitempty"`
+	Prev        string              `json:"prev,omitempty"`
+}
+
+// newsPage wraps the news into a page of the list requested by r. Pages
+// selected by number link to the neighbouring page numbers, pages selected by
+// a cursor link to the neighbouring cursors.
+func newsPage(r *http.Request, news []NewsShortDetailed, p Pagination) NewsPage {
+	if news == nil {
+		news = []NewsShortDetailed{}
+	}
+	page := NewsPage{
+		News:        news,
+		TotalPages:  p.TotalPages,
+		CurrentPage: p.CurrentPage,
+		PageSize:    p.PageSize,
+		TotalItems:  p.TotalItems,
+		NextCursor:  p.NextCursor,
+		PrevCursor:  p.PrevCursor,
+	}
+	if p.CurrentPage > 0 {
+		if p.CurrentPage < p.TotalPages {
+			page.Next = pageLink(r, "page", strconv.Itoa(p.CurrentPage+1))
+		}
+		if p.CurrentPage > 1 && p.TotalPages > 0 {
+			prev := p.CurrentPage - 1
+			if prev > p.TotalPages {
+				prev = p.TotalPages
+			}
+			page.Prev = pageLink(r, "page", strconv.Itoa(prev))
+		}
+		return page
+	}
+	if p.NextCursor != "" {
+		page.Next = pageLink(r, "cursor", p.NextCursor)
+	}
+	if p.PrevCursor != "" {
+		page.Prev = pageLink(r, "cursor", p.PrevCursor)
+	}
+	return page
+}
+
+// pageLink returns the URL of the request with the page or cursor replaced.
+func pageLink(r *http.Request, name, value string) string {
+	query := r.URL.Query()
+	query.Del("page")
+	query.Del("cursor")
+	query.Set(name, value)
+	return r.URL.Path + "?" + query.Encode()
 }
 
 // NewsFullDetailed contains complete information about a news item.
 type NewsFullDetailed struct {
-	ID       int
-	Title    string
-	Content  string
-	PubTime  int64
-	Link     string
-	Comments []Comment
+	ID               int
+	Title            string
+	Content          string
+	ContentHTML      string
+	Article          string
+	PubTime          int64
+	PubTimeEstimated bool
+	Link             string
+	SourceID         int
+	SourceTitle      string
+	Author           string
+	GUID             string
+	ClusterID        int
+	Tags             []string
+	Media            []Media
+	Comments         []Comment
+}
+
+// Media contains information about a media file attached to a news item.
+type Media struct {
+	URL    string // file address
+	Type   string // MIME type
+	Medium string // image, video or audio
+	Width  int    // width in pixels, 0 if unknown
+	Height int    // height in pixels, 0 if unknown
+	Length int64  // size in bytes, 0 if unknown
 }
 
 // NewsShortDetailed contains brief information about the news.
 type NewsShortDetailed struct {
-	ID      int
-	Title   string
-	Content string
-	PubTime int64
-	Link    string
+	ID               int
+	Title            string
+	Content          string
+	PubTime          int64
+	PubTimeEstimated bool
+	Link             string
+	SourceID         int
+	SourceTitle      string
+	Author           string
+	ClusterID        int
+	Tags             []string
+	Snippet          string
+	AlsoReportedBy   []Report
+}
+
+// Report links to another news item about the same story.
+type Report struct {
+	ID          int    // news identifier
+	Title       string // news title
+	Link        string // news link
+	SourceID    int    // identifier of the source feed
+	SourceTitle string // title of the source feed
+}
+
+// Tag contains a news tag with the number of news marked with it.
+type Tag struct {
+	Name  string // tag name
+	Count int    // number of news
 }
 
 // Comment contains information about the comment.
@@ -121,6 +261,10 @@ func GetNewsDetailHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		defer resp.Body.Close()
+		if err := checkStatus(resp, http.StatusOK); err != nil {
+			chResults <- RequestResult{Err: err}
+			return
+		}
 
 		var newsDetail NewsFullDetailed
 		if err := json.NewDecoder(resp.Body).Decode(&newsDetail); err != nil {
@@ -149,6 +293,10 @@ func GetNewsDetailHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		defer resp.Body.Close()
+		if err := checkStatus(resp, http.StatusOK); err != nil {
+			chResults <- RequestResult{Err: err}
+			return
+		}
 
 		var comments []Comment
 		if err := json.NewDecoder(resp.Body).Decode(&comments); err != nil {
@@ -166,6 +314,13 @@ func GetNewsDetailHandler(w http.ResponseWriter, r *http.Request) {
 	var commentsData []Comment
 
 	for result := range chResults {
+		// Client errors of the services, such as a missing news item, are
+		// passed through.
+		var ue *upstreamError
+		if errors.As(result.Err, &ue) && ue.Status >= 400 && ue.Status < 500 {
+			http.Error(w, ue.Message, ue.Status)
+			return
+		}
 		if result.Err != nil {
 			http.Error(w, result.Err.Error(), http.StatusInternalServerError)
 			return
@@ -186,9 +341,24 @@ func GetNewsDetailHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// newsQueryParams are the news list parameters passed to the news service.
+var newsQueryParams = []string{"page", "cursor", "page_size", "s", "from", "to", "source", "tag", "sort", "order"}
+
+// newsQuery returns the news list parameters of the request.
+func newsQuery(r *http.Request) url.Values {
+	query := url.Values{}
+	for _, name := range newsQueryParams {
+		if values := r.URL.Query()[name]; len(values) > 0 {
+			query[name] = values
+		}
+	}
+	return query
+}
+
 func GetNewsListHandler(w http.ResponseWriter, r *http.Request) {
-	pageStr := r.URL.Query().Get("page")
-	resp, err := http.Get("http://localhost:8081/news?page=" + pageStr)
+	query := newsQuery(r)
+	query.Del("s")
+	resp, err := http.Get("http://localhost:8081/news?" + query.Encode())
 	if err != nil {
 		http.Error(w, "Failed to fetch news list", http.StatusInternalServerError)
 		return
@@ -215,17 +385,25 @@ func GetNewsListHandler(w http.ResponseWriter, r *http.Request) {
 		}
 
 		shortNews := NewsShortDetailed{
-			ID:      news.ID,
-			Title:   news.Title,
-			Content: shortContent,
-			PubTime: news.PubTime,
-			Link:    news.Link,
+			ID:               news.ID,
+			Title:            news.Title,
+			Content:          shortContent,
+			PubTime:          news.PubTime,
+			PubTimeEstimated: news.PubTimeEstimated,
+			Link:             news.Link,
+			SourceID:         news.SourceID,
+			SourceTitle:      news.SourceTitle,
+			Author:           news.Author,
+			ClusterID:        news.ClusterID,
+			Tags:             news.Tags,
+			Snippet:          news.Snippet,
+			AlsoReportedBy:   news.AlsoReportedBy,
 		}
 		shortNewsList = append(shortNewsList, shortNews)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(shortNewsList); err != nil {
+	if err := json.NewEncoder(w).Encode(newsPage(r, shortNewsList, newsListResponse.Pagination)); err != nil {
 		http.Error(w, "Failed to encode news list", http.StatusInternalServerError)
 		return
 	}
@@ -233,16 +411,9 @@ func GetNewsListHandler(w http.ResponseWriter, r *http.Request) {
 
 // FilterNewsHandler handles the request to filter the news list.
 func FilterNewsHandler(w http.ResponseWriter, r *http.Request) {
-	var resp *http.Response
-	var err error
-	searchParam := url.QueryEscape(r.URL.Query().Get("s"))
-	pageStr := r.URL.Query().Get("page")
-	log.Println(searchParam)
-	if pageStr != "" {
-		resp, err = http.Get("http://localhost:8081/news?s=" + searchParam + "&page=" + pageStr)
-	} else {
-		resp, err = http.Get("http://localhost:8081/news?s=" + searchParam)
-	}
+	query := newsQuery(r)
+	log.Println(query.Get("s"))
+	resp, err := http.Get("http://localhost:8081/news?" + query.Encode())
 	if err != nil {
 		http.Error(w, "Failed to fetch filtered news list", http.StatusInternalServerError)
 		return
@@ -269,27 +440,62 @@ func FilterNewsHandler(w http.ResponseWriter, r *http.Request) {
 		}
 
 		shortNews := NewsShortDetailed{
-			ID:      news.ID,
-			Title:   news.Title,
-			Content: shortContent,
-			PubTime: news.PubTime,
-			Link:    news.Link,
+			ID:               news.ID,
+			Title:            news.Title,
+			Content:          shortContent,
+			PubTime:          news.PubTime,
+			PubTimeEstimated: news.PubTimeEstimated,
+			Link:             news.Link,
+			SourceID:         news.SourceID,
+			SourceTitle:      news.SourceTitle,
+			Author:           news.Author,
+			ClusterID:        news.ClusterID,
+			Tags:             news.Tags,
+			Snippet:          news.Snippet,
+			AlsoReportedBy:   news.AlsoReportedBy,
 		}
 		filteredNewsList = append(filteredNewsList, shortNews)
 	}
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(filteredNewsList); err != nil {
+	if err := json.NewEncoder(w).Encode(newsPage(r, filteredNewsList, filteredNewsListResponse.Pagination)); err != nil {
 		http.Error(w, "Failed to encode news list", http.StatusInternalServerError)
 		return
 	}
 }
 
+// GetTagsHandler processes a request to get the list of tags with news counts.
+func GetTagsHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := http.Get("http://localhost:8081/tags")
+	if err != nil {
+		http.Error(w, "Failed to fetch tags", http.StatusInternalServerError)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		http.Error(w, "Failed to fetch tags", resp.StatusCode)
+		return
+	}
+	var tags []Tag
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		http.Error(w, "Failed to decode tags", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(tags); err != nil {
+		http.Error(w, "Failed to encode tags", http.StatusInternalServerError)
+		return
+	}
+}
+
 func main() {
 	router := mux.NewRouter()
 	router.HandleFunc("/news/{newsID:[0-9]+}", AddCommentHandler).Methods("POST")
 	router.HandleFunc("/news/{newsID:[0-9]+}", GetNewsDetailHandler).Methods("GET")
 	router.HandleFunc("/news", GetNewsListHandler).Methods("GET")
 	router.HandleFunc("/news/filter", FilterNewsHandler).Methods("GET")
+	router.HandleFunc("/tags", GetTagsHandler).Methods("GET")
 
 	log.Println("API Gateway запущен на порту 8080...")
 	err := http.ListenAndServe(":8080", middleware.RequestIDMiddleware(middleware.LoggingMiddleware(router)))
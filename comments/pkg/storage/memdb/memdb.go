@@ -1,16 +1,67 @@
+// Package memdb is an in-memory storage of comments. It lets the comments
+// service run without a database server.
 package memdb
 
 import (
-	"GoNews/comments/pkg/storage"
+	comStorage "GoNews/comments/pkg/storage"
+	"errors"
+	"sort"
+	"sync"
 )
 
-// Data storage.
-type DB []comStorage.Comment
+// ErrDuplicateID is returned when a comment with the same identifier is stored.
+var ErrDuplicateID = errors.New("memdb: duplicate comment id")
 
-func (db *DB) Comments() ([]comStorage.Comment, error) {
-	return *db, nil
+// Data storage. It is safe for concurrent use.
+type DB struct {
+	mu       sync.RWMutex
+	comments []comStorage.Comment // in the order of addition
+	ids      map[int]bool
+	lastID   int
 }
-func (db *DB) AddComments(comment comStorage.Comment) error {
-	*db = append(*db, comment)
+
+// Constructor creates a new empty DB object.
+func New() *DB {
+	return &DB{ids: make(map[int]bool)}
+}
+
+// Comments returns the comments of the publication, newest first.
+func (db *DB) Comments(n int64) ([]comStorage.Comment, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	var comments []comStorage.Comment
+	for _, c := range db.comments {
+		if c.ID_News == n {
+			comments = append(comments, c)
+		}
+	}
+	// Given identifiers may be out of the order of addition.
+	sort.Slice(comments, func(i, j int) bool { return comments[i].ID > comments[j].ID })
+	return comments, nil
+}
+
+// AddComments creates new comments. Comments without an identifier get the
+// next free one. Either all comments are added or none.
+func (db *DB) AddComments(comments []comStorage.Comment) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	seen := make(map[int]bool)
+	for _, c := range comments {
+		if c.ID != 0 && (db.ids[c.ID] || seen[c.ID]) {
+			return ErrDuplicateID
+		}
+		seen[c.ID] = true
+	}
+	for _, c := range comments {
+		if c.ID == 0 {
+			for db.ids[db.lastID+1] {
+				db.lastID++
+			}
+			db.lastID++
+			c.ID = db.lastID
+		}
+		db.ids[c.ID] = true
+		db.comments = append(db.comments, c)
+	}
 	return nil
 }
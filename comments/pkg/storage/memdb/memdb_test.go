@@ -0,0 +1,13 @@
+package memdb
+
+import (
+	comStorage "GoNews/comments/pkg/storage"
+	"GoNews/comments/pkg/storage/storagetest"
+	"testing"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Comments(t, func(t *testing.T) comStorage.CommentsInterface {
+		return New()
+	})
+}
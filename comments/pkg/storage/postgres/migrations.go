@@ -0,0 +1,33 @@
+package postgres
+
+import (
+	"GoNews/comments/pkg/migrate"
+	"database/sql"
+	"embed"
+	"io/fs"
+
+	_ "github.com/jackc/pgx/v4/stdlib"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Migrator returns the schema migrator of the database. The server does not
+// migrate PostgreSQL on start, the schema is changed by the migrate command.
+func Migrator(constr string) (*migrate.Migrator, error) {
+	db, err := sql.Open("pgx", constr)
+	if err != nil {
+		return nil, err
+	}
+	files, err := fs.Sub(migrations, "migrations")
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	m, err := migrate.New(db, files)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS comments (
+    id SERIAL PRIMARY KEY,
+    id_news BIGINT NOT NULL,
+    id_parent BIGINT,
+    content TEXT NOT NULL,
+    commented_at BIGINT NOT NULL DEFAULT 0
+);
@@ -60,12 +60,21 @@ func (s *Storage) Comments(n int64) ([]comStorage.Comment, error) {
 	return comments, rows.Err()
 }
 
-// AddComments creates a new comments in the database.
+// AddComments creates new comments in a single transaction. A comment with
+// the zero ID gets the next free one.
 func (s *Storage) AddComments(comments []comStorage.Comment) error {
+	ctx := context.Background()
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	var given bool
 	for _, comment := range comments {
-		_, err := s.db.Exec(context.Background(), `
+		given = given || comment.ID != 0
+		_, err = tx.Exec(ctx, `
 		INSERT INTO comments(id, id_news, id_parent, content, commented_at)
-		VALUES ($1, $2, $3, $4, $5)`,
+		VALUES (COALESCE(NULLIF($1, 0), nextval(pg_get_serial_sequence('comments', 'id'))), $2, $3, $4, $5)`,
 			comment.ID,
 			comment.ID_News,
 			comment.ID_Parent,
@@ -76,5 +85,18 @@ func (s *Storage) AddComments(comments []comStorage.Comment) error {
 			return err
 		}
 	}
-	return nil
+	// Given IDs bypass the sequence, which is moved past them so it does
+	// not hand them out again.
+	if given {
+		_, err = tx.Exec(ctx, `
+		SELECT setval(pg_get_serial_sequence('comments', 'id'), GREATEST(
+			MAX(id),
+			COALESCE(pg_sequence_last_value(pg_get_serial_sequence('comments', 'id')::regclass), 0)
+		))
+		FROM comments`)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
 }
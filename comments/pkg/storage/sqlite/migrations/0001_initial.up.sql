@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS comments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    id_news INTEGER NOT NULL,
+    id_parent INTEGER NOT NULL DEFAULT 0,
+    content TEXT NOT NULL,
+    commented_at INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS comments_id_news_idx ON comments(id_news);
@@ -0,0 +1,110 @@
+// Package sqlite stores comments in an SQLite database file. Pending schema
+// migrations are applied on opening.
+package sqlite
+
+import (
+	"GoNews/comments/pkg/storage"
+	"database/sql"
+
+	_ "modernc.org/sqlite"
+)
+
+// Data storage.
+type Storage struct {
+	db *sql.DB
+}
+
+// Constructor creates a new Storage object for the database file, or for an
+// in-memory database if the path is ":memory:".
+func New(path string) (*Storage, error) {
+	db, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	err = migrateUp(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	s := Storage{
+		db: db,
+	}
+	return &s, nil
+}
+
+// open opens the database file.
+func open(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer, and every connection to ":memory:" opens
+	// a separate database.
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// Close closes the database.
+func (s *Storage) Close() error {
+	return s.db.Close()
+}
+
+// Comments returns publication comments from the database.
+func (s *Storage) Comments(n int64) ([]comStorage.Comment, error) {
+	rows, err := s.db.Query(`
+		SELECT 
+			id,
+			id_news,
+			id_parent,
+			content,
+			commented_at
+		FROM comments
+		WHERE id_news = ?1
+		ORDER BY id DESC;
+	`, n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var comments []comStorage.Comment
+	for rows.Next() {
+		var c comStorage.Comment
+		err = rows.Scan(
+			&c.ID,
+			&c.ID_News,
+			&c.ID_Parent,
+			&c.Content,
+			&c.ComTime,
+		)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, c)
+	}
+	return comments, rows.Err()
+}
+
+// AddComments creates new comments in a single transaction. A comment with
+// the zero ID gets the next free one.
+func (s *Storage) AddComments(comments []comStorage.Comment) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, comment := range comments {
+		_, err = tx.Exec(`
+		INSERT INTO comments(id, id_news, id_parent, content, commented_at)
+		VALUES (NULLIF(?1, 0), ?2, ?3, ?4, ?5)`,
+			comment.ID,
+			comment.ID_News,
+			comment.ID_Parent,
+			comment.Content,
+			comment.ComTime,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,18 @@
+package sqlite
+
+import (
+	"GoNews/comments/pkg/storage"
+	"GoNews/comments/pkg/storage/storagetest"
+	"testing"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Comments(t, func(t *testing.T) comStorage.CommentsInterface {
+		s, err := New(":memory:")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { s.Close() })
+		return s
+	})
+}
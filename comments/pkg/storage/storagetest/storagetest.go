@@ -0,0 +1,191 @@
+// Package storagetest is a conformance suite for the implementations of
+// comStorage.CommentsInterface. A backend runs it from its own tests:
+//
+//	func TestConformance(t *testing.T) {
+//		storagetest.Comments(t, func(t *testing.T) comStorage.CommentsInterface {
+//			return memdb.New()
+//		})
+//	}
+package storagetest
+
+import (
+	"GoNews/comments/pkg/storage"
+	"reflect"
+	"testing"
+)
+
+// Opener returns an empty storage for a single test.
+type Opener func(t *testing.T) comStorage.CommentsInterface
+
+// Comments runs the conformance tests against the storages returned by open.
+func Comments(t *testing.T, open Opener) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, db comStorage.CommentsInterface)
+	}{
+		{"Empty", testEmpty},
+		{"RoundTrip", testRoundTrip},
+		{"Ordering", testOrdering},
+		{"News", testNews},
+		{"GivenIDs", testGivenIDs},
+		{"GivenFirstID", testGivenFirstID},
+		{"DuplicateID", testDuplicateID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, open(t))
+		})
+	}
+}
+
+// add stores the comments and fails the test on an error.
+func add(t *testing.T, db comStorage.CommentsInterface, comments ...comStorage.Comment) {
+	t.Helper()
+	err := db.AddComments(comments)
+	if err != nil {
+		t.Fatalf("AddComments() error = %v", err)
+	}
+}
+
+// list returns the comments of the publication and fails the test on an error.
+func list(t *testing.T, db comStorage.CommentsInterface, news int64) []comStorage.Comment {
+	t.Helper()
+	comments, err := db.Comments(news)
+	if err != nil {
+		t.Fatalf("Comments(%d) error = %v", news, err)
+	}
+	return comments
+}
+
+// contents returns the contents of the comments.
+func contents(comments []comStorage.Comment) []string {
+	contents := []string{}
+	for _, c := range comments {
+		contents = append(contents, c.Content)
+	}
+	return contents
+}
+
+func testEmpty(t *testing.T, db comStorage.CommentsInterface) {
+	if comments := list(t, db, 1); len(comments) != 0 {
+		t.Errorf("Comments() = %+v, want none", comments)
+	}
+	add(t, db)
+	if comments := list(t, db, 1); len(comments) != 0 {
+		t.Errorf("Comments() after adding nothing = %+v, want none", comments)
+	}
+}
+
+func testRoundTrip(t *testing.T, db comStorage.CommentsInterface) {
+	want := comStorage.Comment{ID_News: 7, ID_Parent: 0, Content: "Первый комментарий", ComTime: 1700000000}
+	add(t, db, want)
+	comments := list(t, db, 7)
+	if len(comments) != 1 {
+		t.Fatalf("Comments() returned %d comments, want 1", len(comments))
+	}
+	got := comments[0]
+	if got.ID <= 0 {
+		t.Errorf("ID = %d, want a new identifier", got.ID)
+	}
+	want.ID = got.ID
+	if got != want {
+		t.Errorf("Comments() = %+v, want %+v", got, want)
+	}
+
+	// An answer refers to its parent.
+	answer := comStorage.Comment{ID_News: 7, ID_Parent: int64(got.ID), Content: "answer", ComTime: 1700000100}
+	add(t, db, answer)
+	comments = list(t, db, 7)
+	if len(comments) != 2 || comments[0].ID_Parent != int64(got.ID) {
+		t.Errorf("Comments() = %+v, want the answer to %d first", comments, got.ID)
+	}
+}
+
+func testOrdering(t *testing.T, db comStorage.CommentsInterface) {
+	add(t, db, comStorage.Comment{ID_News: 1, Content: "first"}, comStorage.Comment{ID_News: 1, Content: "second"})
+	add(t, db, comStorage.Comment{ID_News: 1, Content: "third"})
+	comments := list(t, db, 1)
+	if got, want := contents(comments), []string{"third", "second", "first"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Comments() = %q, want newest first %q", got, want)
+	}
+	seen := make(map[int]bool)
+	for i, c := range comments {
+		if seen[c.ID] {
+			t.Errorf("ID %d is repeated", c.ID)
+		}
+		seen[c.ID] = true
+		if i > 0 && c.ID >= comments[i-1].ID {
+			t.Errorf("IDs %d, %d are not descending", comments[i-1].ID, c.ID)
+		}
+	}
+}
+
+func testNews(t *testing.T, db comStorage.CommentsInterface) {
+	add(t, db,
+		comStorage.Comment{ID_News: 1, Content: "a"},
+		comStorage.Comment{ID_News: 2, Content: "b"},
+		comStorage.Comment{ID_News: 1, Content: "c"},
+	)
+	tests := []struct {
+		news int64
+		want []string
+	}{
+		{1, []string{"c", "a"}},
+		{2, []string{"b"}},
+		{3, []string{}},
+		{-1, []string{}},
+	}
+	for _, tt := range tests {
+		if got := contents(list(t, db, tt.news)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Comments(%d) = %q, want %q", tt.news, got, tt.want)
+		}
+	}
+}
+
+func testGivenIDs(t *testing.T, db comStorage.CommentsInterface) {
+	add(t, db, comStorage.Comment{ID: 1000, ID_News: 1, Content: "given"}, comStorage.Comment{ID_News: 1, Content: "new"})
+	comments := list(t, db, 1)
+	if len(comments) != 2 {
+		t.Fatalf("Comments() returned %d comments, want 2", len(comments))
+	}
+	ids := make(map[string]int)
+	for _, c := range comments {
+		ids[c.Content] = c.ID
+	}
+	if ids["given"] != 1000 {
+		t.Errorf("given comment ID = %d, want 1000", ids["given"])
+	}
+	if ids["new"] <= 0 || ids["new"] == 1000 {
+		t.Errorf("new comment ID = %d, want a new identifier", ids["new"])
+	}
+}
+
+func testGivenFirstID(t *testing.T, db comStorage.CommentsInterface) {
+	// A given ID is not handed out again to a later comment without one.
+	add(t, db, comStorage.Comment{ID: 1, ID_News: 1, Content: "given"})
+	add(t, db, comStorage.Comment{ID_News: 1, Content: "new"})
+	comments := list(t, db, 1)
+	if len(comments) != 2 {
+		t.Fatalf("Comments() returned %d comments, want 2", len(comments))
+	}
+	for _, c := range comments {
+		if c.Content == "new" && (c.ID <= 0 || c.ID == 1) {
+			t.Errorf("new comment ID = %d, want a new identifier", c.ID)
+		}
+	}
+}
+
+func testDuplicateID(t *testing.T, db comStorage.CommentsInterface) {
+	add(t, db, comStorage.Comment{ID: 1000, ID_News: 1, Content: "stored"})
+	err := db.AddComments([]comStorage.Comment{
+		{ID_News: 1, Content: "valid"},
+		{ID: 1000, ID_News: 1, Content: "duplicate"},
+	})
+	if err == nil {
+		t.Fatal("AddComments() with a stored ID succeeded, want an error")
+	}
+	// Either all comments are added or none.
+	if got, want := contents(list(t, db, 1)), []string{"stored"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Comments() = %q, want %q", got, want)
+	}
+}
@@ -1,11 +1,14 @@
 package api
 
 import (
+	"GoNews/news/pkg/health"
 	newsStorage "GoNews/news/pkg/storage"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
+	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -13,13 +16,15 @@ import (
 // API - API object.
 type API struct {
 	db     newsStorage.NewsInterface
+	feeds  newsStorage.FeedsInterface
+	health *health.Tracker
 	router *mux.Router
 }
 
 // Constructor creates a new API object.
-func New(db newsStorage.NewsInterface) *API {
+func New(db newsStorage.NewsInterface, feeds newsStorage.FeedsInterface, tracker *health.Tracker) *API {
 	api := API{
-		db: db, router: mux.NewRouter(),
+		db: db, feeds: feeds, health: tracker, router: mux.NewRouter(),
 	}
 	api.endpoints()
 	return &api
@@ -29,6 +34,15 @@ func New(db newsStorage.NewsInterface) *API {
 func (api *API) endpoints() {
 	api.router.HandleFunc("/news", api.PostsHandler).Methods(http.MethodGet, http.MethodOptions)
 	api.router.HandleFunc("/news/{id}", api.PostDetailHandler).Methods(http.MethodGet, http.MethodOptions)
+	api.router.HandleFunc("/tags", api.TagsHandler).Methods(http.MethodGet, http.MethodOptions)
+	api.router.HandleFunc("/feeds", api.FeedsHandler).Methods(http.MethodGet, http.MethodOptions)
+	api.router.HandleFunc("/feeds", api.AddFeedHandler).Methods(http.MethodPost)
+	api.router.HandleFunc("/feeds/opml", api.ExportOPMLHandler).Methods(http.MethodGet, http.MethodOptions)
+	api.router.HandleFunc("/feeds/opml", api.ImportOPMLHandler).Methods(http.MethodPost)
+	api.router.HandleFunc("/feeds/health", api.FeedsHealthHandler).Methods(http.MethodGet, http.MethodOptions)
+	api.router.HandleFunc("/feeds/{id:[0-9]+}", api.FeedHandler).Methods(http.MethodGet, http.MethodOptions)
+	api.router.HandleFunc("/feeds/{id:[0-9]+}", api.UpdateFeedHandler).Methods(http.MethodPatch)
+	api.router.HandleFunc("/feeds/{id:[0-9]+}", api.DeleteFeedHandler).Methods(http.MethodDelete)
 
 	api.router.PathPrefix("/").Handler(http.StripPrefix("/", http.FileServer(http.Dir("cmd/server/webapp"))))
 }
@@ -40,38 +54,177 @@ func (api *API) Router() *mux.Router {
 
 // Retrieving a list of news items.
 func (api *API) PostsHandler(w http.ResponseWriter, r *http.Request) {
-	var err error
-	var page int
-	var pagination newsStorage.Pagination
-	var posts []newsStorage.Post
-	searchStr := r.URL.Query().Get("s")
-	fmt.Println(searchStr)
-	pageStr := r.URL.Query().Get("page")
-	if pageStr != "" {
-		page, err = strconv.Atoi(pageStr)
-	} else {
-		page = 1
+	query, err := parseQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
+	posts, pagination, err := api.db.Posts(query)
 	if err != nil {
-		http.Error(w, "Invalid page number", http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	posts, pagination, err = api.db.Posts(page, searchStr)
+
+	stories, err := api.stories(posts)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	responseData := struct {
-		Posts      []newsStorage.Post     `json:"posts"`
+		Posts      []story                `json:"posts"`
 		Pagination newsStorage.Pagination `json:"pagination"`
 	}{
-		Posts:      posts,
+		Posts:      stories,
 		Pagination: pagination,
 	}
 	json.NewEncoder(w).Encode(responseData)
 }
 
+// parseQuery reads the publications query from the request parameters:
+//
+//	page      page number
+//	cursor    next or previous page cursor, replaces the page number
+//	page_size number of news on a page, bounded by the storage
+//	s         full-text search query
+//	from, to  publication time bounds, Unix time, RFC 3339 or a date
+//	source    source feed identifier, may be repeated
+//	tag       tag, may be repeated
+//	sort      id, pubtime or relevance
+//	order     asc or desc
+func parseQuery(v url.Values) (newsStorage.Query, error) {
+	q := newsStorage.Query{
+		Page:   1,
+		Search: v.Get("s"),
+		Tags:   v["tag"],
+	}
+	var err error
+	if s := v.Get("page"); s != "" {
+		q.Page, err = strconv.Atoi(s)
+		if err != nil {
+			return q, errors.New("Invalid page number")
+		}
+	}
+	if s := v.Get("page_size"); s != "" {
+		q.PageSize, err = strconv.Atoi(s)
+		if err != nil {
+			return q, errors.New("Invalid page size")
+		}
+	}
+	if s := v.Get("from"); s != "" {
+		q.From, err = parseTime(s, false)
+		if err != nil {
+			return q, errors.New("Invalid from time")
+		}
+	}
+	if s := v.Get("to"); s != "" {
+		q.To, err = parseTime(s, true)
+		if err != nil {
+			return q, errors.New("Invalid to time")
+		}
+	}
+	for _, s := range v["source"] {
+		id, err := strconv.Atoi(s)
+		if err != nil {
+			return q, errors.New("Invalid source ID")
+		}
+		q.SourceIDs = append(q.SourceIDs, id)
+	}
+	switch q.Sort = v.Get("sort"); q.Sort {
+	case "", newsStorage.SortID, newsStorage.SortPubTime, newsStorage.SortRelevance:
+	default:
+		return q, errors.New("Invalid sort order")
+	}
+	switch v.Get("order") {
+	case "", "desc":
+	case "asc":
+		q.Asc = true
+	default:
+		return q, errors.New("Invalid order")
+	}
+	if s := v.Get("cursor"); s != "" {
+		c, err := newsStorage.ParseCursor(s)
+		if err != nil {
+			return q, errors.New("Invalid cursor")
+		}
+		if q.Sort != "" && q.Sort != c.Sort {
+			return q, errors.New("Cursor does not match the sort order")
+		}
+		q.Cursor = &c
+	}
+	return q, nil
+}
+
+// parseTime parses a time bound given as Unix time, RFC 3339 or a date. A
+// date is the start of the day, or its end for an upper bound.
+func parseTime(s string, upper bool) (int64, error) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.Unix(), nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return 0, err
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	return t.Unix(), nil
+}
+
+// story is a publication listed with the other reports of the same story.
+type story struct {
+	newsStorage.Post
+	AlsoReportedBy []report
+}
+
+// report is a link to another publication of the story.
+type report struct {
+	ID          int
+	Title       string
+	Link        string
+	SourceID    int
+	SourceTitle string
+}
+
+// stories collapses the story clusters of the publications into single
+// entries linking to the other reports.
+func (api *API) stories(posts []newsStorage.Post) ([]story, error) {
+	stories := make([]story, 0, len(posts))
+	clusters := make([]int, 0, len(posts))
+	for _, p := range posts {
+		stories = append(stories, story{Post: p, AlsoReportedBy: []report{}})
+		clusters = append(clusters, p.ClusterID)
+	}
+	if len(clusters) == 0 {
+		return stories, nil
+	}
+	reports, err := api.db.ClusterPosts(clusters)
+	if err != nil {
+		return nil, err
+	}
+	byCluster := make(map[int][]report)
+	for _, p := range reports {
+		byCluster[p.ClusterID] = append(byCluster[p.ClusterID], report{
+			ID:          p.ID,
+			Title:       p.Title,
+			Link:        p.Link,
+			SourceID:    p.SourceID,
+			SourceTitle: p.SourceTitle,
+		})
+	}
+	for i := range stories {
+		for _, r := range byCluster[stories[i].ClusterID] {
+			if r.ID != stories[i].ID {
+				stories[i].AlsoReportedBy = append(stories[i].AlsoReportedBy, r)
+			}
+		}
+	}
+	return stories, nil
+}
+
 // Getting detailed information about the news.
 func (api *API) PostDetailHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -82,6 +235,10 @@ func (api *API) PostDetailHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	post, err := api.db.PostDetail(id)
+	if errors.Is(err, newsStorage.ErrNotFound) {
+		http.Error(w, "News not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -89,3 +246,23 @@ func (api *API) PostDetailHandler(w http.ResponseWriter, r *http.Request) {
 
 	json.NewEncoder(w).Encode(post)
 }
+
+// Getting the list of tags with publication counts.
+func (api *API) TagsHandler(w http.ResponseWriter, r *http.Request) {
+	tags, err := api.db.Tags()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if tags == nil {
+		tags = []newsStorage.Tag{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+}
+
+// Getting the polling state of news feeds.
+func (api *API) FeedsHealthHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(api.health.Feeds())
+}
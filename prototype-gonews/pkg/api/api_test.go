@@ -0,0 +1,91 @@
+package api
+
+import (
+	newsStorage "GoNews/news/pkg/storage"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+// addPost stores a distinct publication numbered i.
+func addPost(t *testing.T, api *API, i int) {
+	t.Helper()
+	_, err := api.db.AddPosts([]newsStorage.Post{{
+		Title:   fmt.Sprintf("Report %d", i),
+		Content: fmt.Sprintf("Publication number %d of the test", i),
+		PubTime: int64(1000 + i),
+		Link:    fmt.Sprintf("https://example.com/news/%d", i),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// listPosts requests a page of publications and returns their identifiers
+// and the pagination.
+func listPosts(t *testing.T, api *API, target string) ([]int, newsStorage.Pagination) {
+	t.Helper()
+	rec := serve(api, http.MethodGet, target, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s status = %d, want %d: %s", target, rec.Code, http.StatusOK, rec.Body)
+	}
+	var res struct {
+		Posts      []newsStorage.Post
+		Pagination newsStorage.Pagination
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	ids := []int{}
+	for _, p := range res.Posts {
+		ids = append(ids, p.ID)
+	}
+	return ids, res.Pagination
+}
+
+func TestPostsCursorsWhileAdding(t *testing.T) {
+	api, _ := newTestAPI(t)
+	for i := 0; i < 5; i++ {
+		addPost(t, api, i)
+	}
+	want, _ := listPosts(t, api, "/news")
+
+	// Publications added between the pages go before the first page and do
+	// not shift the following ones.
+	got, p := listPosts(t, api, "/news?page_size=2")
+	for i := 5; p.NextCursor != "" && len(got) <= len(want); i++ {
+		addPost(t, api, i)
+		var ids []int
+		ids, p = listPosts(t, api, "/news?page_size=2&cursor="+url.QueryEscape(p.NextCursor))
+		got = append(got, ids...)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("pages = %v, want %v", got, want)
+	}
+}
+
+func TestPostsCursorSort(t *testing.T) {
+	api, _ := newTestAPI(t)
+	for i := 0; i < 3; i++ {
+		addPost(t, api, i)
+	}
+	_, p := listPosts(t, api, "/news?page_size=2")
+	cursor := url.QueryEscape(p.NextCursor)
+	tests := []struct {
+		target string
+		want   int
+	}{
+		{"/news?page_size=2&cursor=" + cursor, http.StatusOK},
+		{"/news?page_size=2&sort=id&cursor=" + cursor, http.StatusOK},
+		{"/news?page_size=2&sort=pubtime&cursor=" + cursor, http.StatusBadRequest},
+		{"/news?cursor=invalid", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if rec := serve(api, http.MethodGet, tt.target, ""); rec.Code != tt.want {
+			t.Errorf("GET %s status = %d, want %d", tt.target, rec.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"GoNews/news/pkg/opml"
+	newsStorage "GoNews/news/pkg/storage"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// feedPatch holds the fields of a partial news source update.
+type feedPatch struct {
+	URL      *string
+	Title    *string
+	Category *string
+	Paused   *bool
+	Interval *int
+	FullText *bool
+}
+
+// Getting the list of news sources.
+func (api *API) FeedsHandler(w http.ResponseWriter, r *http.Request) {
+	feeds, err := api.feeds.Feeds()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if feeds == nil {
+		feeds = []newsStorage.Feed{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(feeds)
+}
+
+// Getting a news source.
+func (api *API) FeedHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid feed ID", http.StatusBadRequest)
+		return
+	}
+	feed, err := api.feeds.Feed(id)
+	if err != nil {
+		feedError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(feed)
+}
+
+// Adding a news source.
+func (api *API) AddFeedHandler(w http.ResponseWriter, r *http.Request) {
+	var feed newsStorage.Feed
+	if err := json.NewDecoder(r.Body).Decode(&feed); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := newsStorage.ValidateFeed(feed); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	id, err := api.feeds.AddFeed(feed)
+	if err != nil {
+		feedError(w, err)
+		return
+	}
+	feed.ID = id
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(feed)
+}
+
+// Updating a news source: changing its address, title or folder, pausing or
+// resuming polling and setting the poll interval.
+func (api *API) UpdateFeedHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid feed ID", http.StatusBadRequest)
+		return
+	}
+	var patch feedPatch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	feed, err := api.feeds.Feed(id)
+	if err != nil {
+		feedError(w, err)
+		return
+	}
+	if patch.URL != nil {
+		feed.URL = *patch.URL
+	}
+	if patch.Title != nil {
+		feed.Title = *patch.Title
+	}
+	if patch.Category != nil {
+		feed.Category = *patch.Category
+	}
+	if patch.Paused != nil {
+		feed.Paused = *patch.Paused
+	}
+	if patch.Interval != nil {
+		feed.Interval = *patch.Interval
+	}
+	if patch.FullText != nil {
+		feed.FullText = *patch.FullText
+	}
+	if err := newsStorage.ValidateFeed(*feed); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := api.feeds.UpdateFeed(*feed); err != nil {
+		feedError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(feed)
+}
+
+// Deleting a news source.
+func (api *API) DeleteFeedHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid feed ID", http.StatusBadRequest)
+		return
+	}
+	if err := api.feeds.DeleteFeed(id); err != nil {
+		feedError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Exporting news sources as an OPML document.
+func (api *API) ExportOPMLHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/x-opml; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="feeds.opml"`)
+	if err := opml.Export(api.feeds, w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Importing news sources from an OPML document.
+func (api *API) ImportOPMLHandler(w http.ResponseWriter, r *http.Request) {
+	n, err := opml.Import(api.feeds, r.Body)
+	if errors.Is(err, opml.ErrInvalid) {
+		http.Error(w, "Invalid OPML document", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Imported int `json:"imported"`
+	}{
+		Imported: n,
+	})
+}
+
+// feedError writes the storage error with the matching status code.
+func feedError(w http.ResponseWriter, err error) {
+	if errors.Is(err, newsStorage.ErrNotFound) {
+		http.Error(w, "Feed not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, newsStorage.ErrFeedExists) {
+		http.Error(w, "Feed already exists", http.StatusConflict)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"GoNews/news/pkg/health"
+	newsStorage "GoNews/news/pkg/storage"
+	"GoNews/news/pkg/storage/memdb"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// newTestAPI returns an API over an in-memory storage with one news source.
+func newTestAPI(t *testing.T) (*API, int) {
+	db := memdb.New()
+	id, err := db.AddFeed(newsStorage.Feed{URL: "https://a.example.com/rss", Title: "A", Category: "News", Interval: 15})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(db, db, health.NewTracker(health.Policy{})), id
+}
+
+// serve sends the request to the API and returns the response recorder.
+func serve(api *API, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	api.Router().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAddFeed(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"valid", `{"URL": "https://b.example.com/rss", "Title": "B"}`, http.StatusCreated},
+		{"invalid body", `{"URL": `, http.StatusBadRequest},
+		{"invalid URL", `{"URL": "ftp://b.example.com/rss"}`, http.StatusBadRequest},
+		{"relative URL", `{"URL": "/rss"}`, http.StatusBadRequest},
+		{"negative interval", `{"URL": "https://b.example.com/rss", "Interval": -1}`, http.StatusBadRequest},
+		{"duplicate", `{"URL": "https://a.example.com/rss"}`, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, _ := newTestAPI(t)
+			rec := serve(api, http.MethodPost, "/feeds", tt.body)
+			if rec.Code != tt.want {
+				t.Fatalf("POST /feeds status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if rec.Code != http.StatusCreated {
+				return
+			}
+			var got newsStorage.Feed
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			stored, err := api.feeds.Feed(got.ID)
+			if err != nil {
+				t.Fatalf("Feed(%d) error = %v", got.ID, err)
+			}
+			if *stored != got {
+				t.Errorf("stored feed = %+v, want %+v", *stored, got)
+			}
+		})
+	}
+}
+
+func TestMissingFeed(t *testing.T) {
+	api, id := newTestAPI(t)
+	missing := "/feeds/" + strconv.Itoa(id+100)
+	for _, method := range []string{http.MethodGet, http.MethodDelete, http.MethodPatch} {
+		rec := serve(api, method, missing, `{"Title": "X"}`)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s %s status = %d, want %d", method, missing, rec.Code, http.StatusNotFound)
+		}
+	}
+}
+
+func TestGetAndDeleteFeed(t *testing.T) {
+	api, id := newTestAPI(t)
+	path := "/feeds/" + strconv.Itoa(id)
+	rec := serve(api, http.MethodGet, path, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s status = %d, want %d", path, rec.Code, http.StatusOK)
+	}
+	var got newsStorage.Feed
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.URL != "https://a.example.com/rss" || got.Title != "A" {
+		t.Errorf("GET %s = %+v, want the stored feed", path, got)
+	}
+	if rec := serve(api, http.MethodDelete, path, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE %s status = %d, want %d", path, rec.Code, http.StatusNoContent)
+	}
+	if rec := serve(api, http.MethodGet, path, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET %s after DELETE status = %d, want %d", path, rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestUpdateFeed(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		want   newsStorage.Feed // expected stored feed without the ID
+	}{
+		{"title only", `{"Title": "Renamed"}`, http.StatusOK,
+			newsStorage.Feed{URL: "https://a.example.com/rss", Title: "Renamed", Category: "News", Interval: 15}},
+		{"pause", `{"Paused": true}`, http.StatusOK,
+			newsStorage.Feed{URL: "https://a.example.com/rss", Title: "A", Category: "News", Paused: true, Interval: 15}},
+		{"interval and full text", `{"Interval": 0, "FullText": true}`, http.StatusOK,
+			newsStorage.Feed{URL: "https://a.example.com/rss", Title: "A", Category: "News", FullText: true}},
+		{"empty patch", `{}`, http.StatusOK,
+			newsStorage.Feed{URL: "https://a.example.com/rss", Title: "A", Category: "News", Interval: 15}},
+		{"invalid URL", `{"URL": "not a url"}`, http.StatusBadRequest,
+			newsStorage.Feed{URL: "https://a.example.com/rss", Title: "A", Category: "News", Interval: 15}},
+		{"duplicate URL", `{"URL": "https://b.example.com/rss"}`, http.StatusConflict,
+			newsStorage.Feed{URL: "https://a.example.com/rss", Title: "A", Category: "News", Interval: 15}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, id := newTestAPI(t)
+			if _, err := api.feeds.AddFeed(newsStorage.Feed{URL: "https://b.example.com/rss"}); err != nil {
+				t.Fatal(err)
+			}
+			path := "/feeds/" + strconv.Itoa(id)
+			rec := serve(api, http.MethodPatch, path, tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("PATCH %s status = %d, want %d: %s", path, rec.Code, tt.status, rec.Body)
+			}
+			got, err := api.feeds.Feed(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := tt.want
+			want.ID = id
+			if *got != want {
+				t.Errorf("stored feed = %+v, want %+v", *got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+// Package dedup detects near-duplicate publications, such as one wire story
+// republished by several sources, and groups them into story clusters.
+//
+// Publications are compared by SimHash fingerprints of their word shingles:
+// similar texts have fingerprints differing in a few bits only.
+package dedup
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+)
+
+const (
+	// MaxDistance is the largest Hamming distance between the fingerprints
+	// of near-duplicate publications.
+	MaxDistance = 8
+	// Window is the largest difference in seconds between the publication
+	// times of the reports of one story.
+	Window int64 = 48 * 60 * 60
+	// Number of consecutive words in a shingle.
+	shingleSize = 2
+)
+
+// Fingerprint returns the SimHash of the publication title and content. The
+// fingerprint of a text without words is 0.
+func Fingerprint(title, content string) uint64 {
+	words := words(title + "\n" + content)
+	if len(words) == 0 {
+		return 0
+	}
+	n := shingleSize
+	if len(words) < n {
+		n = len(words)
+	}
+	var weights [64]int
+	for i := 0; i+n <= len(words); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words[i:i+n], " ")))
+		sum := h.Sum64()
+		for b := 0; b < 64; b++ {
+			if sum&(1<<uint(b)) != 0 {
+				weights[b]++
+			} else {
+				weights[b]--
+			}
+		}
+	}
+	var fp uint64
+	for b, w := range weights {
+		if w > 0 {
+			fp |= 1 << uint(b)
+		}
+	}
+	return fp
+}
+
+// Distance returns the number of differing bits of the fingerprints.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// words splits the text into lowercase words.
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// Entry is a clustered publication.
+type Entry struct {
+	ID          int    // publication identifier
+	Cluster     int    // identifier of the first publication of the story
+	Fingerprint uint64 // SimHash of the publication
+	PubTime     int64  // publication time
+}
+
+// Index finds the story clusters of new publications among the known ones.
+type Index struct {
+	entries []Entry
+}
+
+// NewIndex returns an index of the known publications.
+func NewIndex(entries []Entry) *Index {
+	return &Index{entries: entries}
+}
+
+// Add returns the cluster of the publication and adds it to the index. The
+// publication joins the cluster of the closest near-duplicate published
+// within the window, otherwise it starts a new cluster identified by its ID.
+func (x *Index) Add(id int, fp uint64, pubTime int64) int {
+	cluster := id
+	if fp != 0 {
+		best := MaxDistance + 1
+		for _, e := range x.entries {
+			if e.Fingerprint == 0 || e.ID == id || abs(e.PubTime-pubTime) > Window {
+				continue
+			}
+			if d := Distance(e.Fingerprint, fp); d < best {
+				best, cluster = d, e.Cluster
+			}
+		}
+	}
+	x.entries = append(x.entries, Entry{ID: id, Cluster: cluster, Fingerprint: fp, PubTime: pubTime})
+	return cluster
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,169 @@
+package dedup
+
+import "testing"
+
+func TestFingerprint(t *testing.T) {
+	const story = "The city council voted on Tuesday to build twelve miles of protected bike lanes, " +
+		"ending a debate that has lasted for more than three years. The plan connects the downtown " +
+		"business district with the university campus, the riverside park and the new train station."
+	tests := []struct {
+		name      string
+		a, b      [2]string // title and content
+		duplicate bool
+	}{
+		{
+			name:      "same text",
+			a:         [2]string{"Council approves bike lanes", story},
+			b:         [2]string{"Council approves bike lanes", story},
+			duplicate: true,
+		},
+		{
+			name:      "case and punctuation",
+			a:         [2]string{"Council approves bike lanes", story},
+			b:         [2]string{"COUNCIL APPROVES BIKE LANES!", "<p>" + story + "</p>"},
+			duplicate: true,
+		},
+		{
+			name:      "republished with a dateline",
+			a:         [2]string{"Council approves bike lanes", story},
+			b:         [2]string{"City council approves bike lanes", "CITY (AP) - " + story},
+			duplicate: true,
+		},
+		{
+			name: "different story",
+			a:    [2]string{"Council approves bike lanes", story},
+			b: [2]string{"Bakery wins national award", "A local bakery won the national award for " +
+				"the best sourdough bread in the country on Sunday, beating hundreds of competitors " +
+				"from every region after a blind tasting by a panel of chefs and critics."},
+			duplicate: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Distance(Fingerprint(tt.a[0], tt.a[1]), Fingerprint(tt.b[0], tt.b[1]))
+			if got := d <= MaxDistance; got != tt.duplicate {
+				t.Errorf("Distance() = %d, duplicate = %v, want %v", d, got, tt.duplicate)
+			}
+		})
+	}
+}
+
+func TestFingerprintEmpty(t *testing.T) {
+	for _, s := range []string{"", " \n\t", "<>!?"} {
+		if got := Fingerprint(s, s); got != 0 {
+			t.Errorf("Fingerprint(%q) = %#x, want 0", s, got)
+		}
+	}
+	if Fingerprint("Word", "") == 0 {
+		t.Errorf("Fingerprint(%q) = 0, want non-zero", "Word")
+	}
+}
+
+func TestIndexAdd(t *testing.T) {
+	const fp = 0x00ff_00ff_00ff_00ff
+	tests := []struct {
+		name    string
+		entries []Entry
+		fp      uint64
+		pubTime int64
+		want    int
+	}{
+		{
+			name: "no entries",
+			fp:   fp,
+			want: 10,
+		},
+		{
+			name:    "same fingerprint",
+			entries: []Entry{{ID: 1, Cluster: 1, Fingerprint: fp}},
+			fp:      fp,
+			want:    1,
+		},
+		{
+			name:    "at MaxDistance",
+			entries: []Entry{{ID: 1, Cluster: 1, Fingerprint: fp}},
+			fp:      fp ^ 0xff00,
+			want:    1,
+		},
+		{
+			name:    "over MaxDistance",
+			entries: []Entry{{ID: 1, Cluster: 1, Fingerprint: fp}},
+			fp:      fp ^ 0x1ff00,
+			want:    10,
+		},
+		{
+			name:    "at the window edge",
+			entries: []Entry{{ID: 1, Cluster: 1, Fingerprint: fp, PubTime: 0}},
+			fp:      fp,
+			pubTime: Window,
+			want:    1,
+		},
+		{
+			name:    "out of the window",
+			entries: []Entry{{ID: 1, Cluster: 1, Fingerprint: fp, PubTime: 0}},
+			fp:      fp,
+			pubTime: -Window - 1,
+			want:    10,
+		},
+		{
+			name:    "zero fingerprint starts a cluster",
+			entries: []Entry{{ID: 1, Cluster: 1, Fingerprint: 0}},
+			fp:      0,
+			want:    10,
+		},
+		{
+			name:    "zero fingerprint entries skipped",
+			entries: []Entry{{ID: 1, Cluster: 1, Fingerprint: 0}},
+			fp:      0x3,
+			want:    10,
+		},
+		{
+			name: "closest candidate wins",
+			entries: []Entry{
+				{ID: 1, Cluster: 1, Fingerprint: fp ^ 0xf},
+				{ID: 2, Cluster: 1, Fingerprint: fp ^ 0xf0},
+				{ID: 3, Cluster: 3, Fingerprint: fp ^ 0x1},
+				{ID: 4, Cluster: 4, Fingerprint: fp ^ 0x3},
+			},
+			fp:   fp,
+			want: 3,
+		},
+		{
+			name: "first of equal candidates wins",
+			entries: []Entry{
+				{ID: 1, Cluster: 1, Fingerprint: fp ^ 0x1},
+				{ID: 2, Cluster: 2, Fingerprint: fp ^ 0x2},
+			},
+			fp:   fp,
+			want: 1,
+		},
+		{
+			name:    "own entry skipped",
+			entries: []Entry{{ID: 10, Cluster: 7, Fingerprint: fp}},
+			fp:      fp,
+			want:    10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x := NewIndex(tt.entries)
+			if got := x.Add(10, tt.fp, tt.pubTime); got != tt.want {
+				t.Errorf("Add() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexAddChain(t *testing.T) {
+	// Added publications are candidates for the following ones.
+	x := NewIndex(nil)
+	const fp = 0xdead_beef_dead_beef
+	for i, want := range []int{1, 1, 1} {
+		if got := x.Add(i+1, fp^uint64(i), int64(i)*Window); got != want {
+			t.Errorf("Add(%d) = %d, want %d", i+1, got, want)
+		}
+	}
+	if got := x.Add(4, fp, 3*Window); got != 1 {
+		t.Errorf("Add(4) = %d, want 1", got)
+	}
+}
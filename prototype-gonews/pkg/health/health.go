@@ -0,0 +1,151 @@
+// Package health tracks the polling state of news feeds.
+package health
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Feed describes the polling state of a single feed.
+type Feed struct {
+	URL                 string // feed address
+	ConsecutiveFailures int    // failed attempts since the last success
+	LastSuccess         int64  // time of the last successful poll
+	LastError           int64  // time of the last failed poll
+	LastErrorMessage    string // error of the last failed poll
+	FailingSince        int64  // time of the first failure in the current series
+	NextAttempt         int64  // time of the next scheduled poll
+	Quarantined         bool   // the feed has been failing for too long
+}
+
+// Policy defines how failed feeds are retried.
+type Policy struct {
+	BaseBackoff     time.Duration // delay after the first failure
+	MaxBackoff      time.Duration // upper bound of the delay
+	QuarantineAfter time.Duration // failing period after which the feed is quarantined
+}
+
+// Tracker collects the state of feeds. It is safe for concurrent use.
+type Tracker struct {
+	policy Policy
+	mu     sync.Mutex
+	feeds  map[string]*Feed
+	rnd    *rand.Rand
+	now    func() time.Time // clock, replaced in tests
+}
+
+// NewTracker creates a tracker with the given retry policy.
+func NewTracker(p Policy) *Tracker {
+	return &Tracker{
+		policy: p,
+		feeds:  make(map[string]*Feed),
+		rnd:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		now:    time.Now,
+	}
+}
+
+// Track registers the feed so it is reported before its first poll.
+func (t *Tracker) Track(url string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.feed(url)
+}
+
+// Forget removes the feed from the tracker.
+func (t *Tracker) Forget(url string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.feeds, url)
+}
+
+// Success records a successful poll and returns the delay before the next one.
+// A feed which is not tracked, such as a removed one, is not recorded.
+func (t *Tracker) Success(url string, period time.Duration) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	f := t.tracked(url)
+	f.ConsecutiveFailures = 0
+	f.FailingSince = 0
+	f.Quarantined = false
+	f.LastSuccess = now.Unix()
+	f.NextAttempt = now.Add(period).Unix()
+	return period
+}
+
+// Failure records a failed poll and returns the delay before the next one.
+// The delay grows exponentially with jitter; quarantined feeds are only
+// probed once per quarantine period. A feed which is not tracked is not
+// recorded.
+func (t *Tracker) Failure(url string, err error) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	f := t.tracked(url)
+	f.ConsecutiveFailures++
+	f.LastError = now.Unix()
+	f.LastErrorMessage = err.Error()
+	if f.FailingSince == 0 {
+		f.FailingSince = now.Unix()
+	}
+	if t.policy.QuarantineAfter > 0 && now.Sub(time.Unix(f.FailingSince, 0)) >= t.policy.QuarantineAfter {
+		f.Quarantined = true
+	}
+	delay := t.backoff(f.ConsecutiveFailures)
+	if f.Quarantined {
+		delay = t.policy.QuarantineAfter
+	}
+	f.NextAttempt = now.Add(delay).Unix()
+	return delay
+}
+
+// Feeds returns a snapshot of all tracked feeds ordered by URL.
+func (t *Tracker) Feeds() []Feed {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	feeds := make([]Feed, 0, len(t.feeds))
+	for _, f := range t.feeds {
+		feeds = append(feeds, *f)
+	}
+	sort.Slice(feeds, func(i, j int) bool { return feeds[i].URL < feeds[j].URL })
+	return feeds
+}
+
+// feed returns the state of the feed, creating it if needed.
+func (t *Tracker) feed(url string) *Feed {
+	f, ok := t.feeds[url]
+	if !ok {
+		f = &Feed{URL: url}
+		t.feeds[url] = f
+	}
+	return f
+}
+
+// tracked returns the state of the tracked feed, or a detached state that is
+// not stored if the feed is not tracked.
+func (t *Tracker) tracked(url string) *Feed {
+	f, ok := t.feeds[url]
+	if !ok {
+		return &Feed{URL: url}
+	}
+	return f
+}
+
+// backoff returns a random delay between a half and the whole of
+// BaseBackoff * 2^(failures-1), capped by MaxBackoff.
+func (t *Tracker) backoff(failures int) time.Duration {
+	d := t.policy.BaseBackoff
+	for i := 1; i < failures && d < t.policy.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > t.policy.MaxBackoff {
+		d = t.policy.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := int64(d / 2)
+	return time.Duration(half + t.rnd.Int63n(half+1))
+}
@@ -0,0 +1,128 @@
+package health
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// clock is a fake time source advanced by the tests.
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+// newTracker returns a tracker with a fake clock and a seeded random source.
+func newTracker(p Policy) (*Tracker, *clock) {
+	c := &clock{t: time.Date(2024, time.March, 4, 10, 0, 0, 0, time.UTC)}
+	tr := NewTracker(p)
+	tr.now = c.now
+	tr.rnd = rand.New(rand.NewSource(1))
+	return tr, c
+}
+
+var errPoll = errors.New("connection refused")
+
+func TestBackoff(t *testing.T) {
+	policy := Policy{BaseBackoff: time.Minute, MaxBackoff: 10 * time.Minute}
+	tr, _ := newTracker(policy)
+	tr.Track("https://example.com/rss")
+	// The ceiling doubles with every failure up to MaxBackoff, and the delay
+	// lies between its half and the whole.
+	ceilings := []time.Duration{
+		time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute,
+		10 * time.Minute, 10 * time.Minute,
+	}
+	for i, ceiling := range ceilings {
+		got := tr.Failure("https://example.com/rss", errPoll)
+		if got < ceiling/2 || got > ceiling {
+			t.Errorf("Failure() #%d = %v, want between %v and %v", i+1, got, ceiling/2, ceiling)
+		}
+	}
+}
+
+func TestJitter(t *testing.T) {
+	tr, _ := newTracker(Policy{BaseBackoff: time.Minute, MaxBackoff: time.Minute})
+	seen := make(map[time.Duration]bool)
+	for i := 0; i < 100; i++ {
+		d := tr.backoff(1)
+		if d < 30*time.Second || d > time.Minute {
+			t.Fatalf("backoff(1) = %v, want between 30s and 1m", d)
+		}
+		seen[d] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("backoff(1) returned %d distinct delays in 100 calls, want jitter", len(seen))
+	}
+}
+
+func TestQuarantine(t *testing.T) {
+	policy := Policy{BaseBackoff: time.Minute, MaxBackoff: 10 * time.Minute, QuarantineAfter: time.Hour}
+	tr, c := newTracker(policy)
+	url := "https://example.com/rss"
+	tr.Track(url)
+	for i := 0; i < 6; i++ {
+		tr.Failure(url, errPoll)
+		c.advance(10 * time.Minute)
+	}
+	// 50 minutes after the first failure.
+	if f := tr.Feeds()[0]; f.Quarantined || f.ConsecutiveFailures != 6 {
+		t.Fatalf("after 50m: Quarantined = %v, ConsecutiveFailures = %d, want false, 6", f.Quarantined, f.ConsecutiveFailures)
+	}
+	c.advance(10 * time.Minute)
+	if got := tr.Failure(url, errPoll); got != policy.QuarantineAfter {
+		t.Errorf("Failure() of a quarantined feed = %v, want %v", got, policy.QuarantineAfter)
+	}
+	f := tr.Feeds()[0]
+	if !f.Quarantined {
+		t.Error("after 1h: Quarantined = false, want true")
+	}
+	if f.LastErrorMessage != errPoll.Error() {
+		t.Errorf("LastErrorMessage = %q, want %q", f.LastErrorMessage, errPoll.Error())
+	}
+	if want := c.now().Add(policy.QuarantineAfter).Unix(); f.NextAttempt != want {
+		t.Errorf("NextAttempt = %d, want %d", f.NextAttempt, want)
+	}
+}
+
+func TestSuccessResets(t *testing.T) {
+	policy := Policy{BaseBackoff: time.Minute, MaxBackoff: 10 * time.Minute, QuarantineAfter: time.Hour}
+	tr, c := newTracker(policy)
+	url := "https://example.com/rss"
+	tr.Track(url)
+	tr.Failure(url, errPoll)
+	c.advance(2 * time.Hour)
+	tr.Failure(url, errPoll)
+	c.advance(time.Minute)
+	if got := tr.Success(url, 5*time.Minute); got != 5*time.Minute {
+		t.Errorf("Success() = %v, want %v", got, 5*time.Minute)
+	}
+	f := tr.Feeds()[0]
+	if f.ConsecutiveFailures != 0 || f.FailingSince != 0 || f.Quarantined {
+		t.Errorf("after Success: ConsecutiveFailures = %d, FailingSince = %d, Quarantined = %v, want 0, 0, false",
+			f.ConsecutiveFailures, f.FailingSince, f.Quarantined)
+	}
+	if f.LastSuccess != c.now().Unix() {
+		t.Errorf("LastSuccess = %d, want %d", f.LastSuccess, c.now().Unix())
+	}
+	// The next failure starts a new series from the base backoff.
+	if got := tr.Failure(url, errPoll); got > policy.BaseBackoff {
+		t.Errorf("Failure() after Success = %v, want at most %v", got, policy.BaseBackoff)
+	}
+}
+
+func TestForget(t *testing.T) {
+	tr, _ := newTracker(Policy{BaseBackoff: time.Minute, MaxBackoff: time.Minute})
+	tr.Track("https://b.example.com/rss")
+	tr.Track("https://a.example.com/rss")
+	tr.Forget("https://b.example.com/rss")
+	// A poll finishing after the feed is removed does not bring it back.
+	tr.Success("https://b.example.com/rss", time.Minute)
+	tr.Failure("https://b.example.com/rss", errPoll)
+	feeds := tr.Feeds()
+	if len(feeds) != 1 || feeds[0].URL != "https://a.example.com/rss" {
+		t.Errorf("Feeds() = %+v, want only https://a.example.com/rss", feeds)
+	}
+}
@@ -0,0 +1,364 @@
+// Package migrate applies versioned schema migrations to a database. The
+// applied versions are recorded in the schema_migrations table.
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ErrUnknownVersion is returned when the database has a migration applied
+// which is missing from the binary, or a target version does not exist.
+var ErrUnknownVersion = errors.New("migrate: unknown version")
+
+// Migration is a versioned change of the database schema.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string // statements applying the change
+	Down    string // statements reverting the change
+}
+
+// Status is the state of a migration in the database.
+type Status struct {
+	Migration
+	AppliedAt int64 // unix time, 0 if the migration is pending
+}
+
+// fileName matches the migration files: 0001_create_posts.up.sql.
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Load reads the migrations from the files <version>_<name>.up.sql and
+// <version>_<name>.down.sql in the root of fsys. Every version needs both
+// files. The migrations are sorted by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int]*Migration)
+	for _, e := range entries {
+		m := fileName.FindStringSubmatch(e.Name())
+		if e.IsDir() || m == nil {
+			continue
+		}
+		version, err := strconv.Atoi(m[1])
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migrate: invalid version in %s", e.Name())
+		}
+		b, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+		mig := byVersion[version]
+		if mig == nil {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		}
+		if mig.Name != m[2] {
+			return nil, fmt.Errorf("migrate: version %d has names %s and %s", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(b)
+		} else {
+			mig.Down = string(b)
+		}
+	}
+	var migrations []Migration
+	for _, mig := range byVersion {
+		if mig.Up == "" || mig.Down == "" {
+			return nil, fmt.Errorf("migrate: version %d needs both up and down files", mig.Version)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator applies and reverts the migrations. Every migration runs in its
+// own transaction together with its record in schema_migrations, so a
+// failed migration leaves no trace, and concurrent migrators cannot apply
+// the same version twice.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// Constructor creates a new Migrator object for the database with the
+// migrations loaded from fsys.
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	m := Migrator{
+		db:         db,
+		migrations: migrations,
+	}
+	return &m, nil
+}
+
+// Close closes the database.
+func (m *Migrator) Close() error {
+	return m.db.Close()
+}
+
+// Latest returns the version of the last known migration, or 0 if there are
+// none.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// init creates the schema_migrations table.
+func (m *Migrator) init() error {
+	_, err := m.db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at BIGINT NOT NULL
+		)`)
+	return err
+}
+
+// applied returns the application times of the applied versions.
+func (m *Migrator) applied() (map[int]int64, error) {
+	err := m.init()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := m.db.Query(`
+		SELECT
+			version,
+			applied_at
+		FROM schema_migrations;
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := make(map[int]int64)
+	for rows.Next() {
+		var version int
+		var at int64
+		err = rows.Scan(
+			&version,
+			&at,
+		)
+		if err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+// check returns ErrUnknownVersion if a version applied to the database is
+// missing from the migrations.
+func (m *Migrator) check(applied map[int]int64) error {
+	for version := range applied {
+		if m.index(version) < 0 {
+			return fmt.Errorf("%w %d in the database", ErrUnknownVersion, version)
+		}
+	}
+	return nil
+}
+
+// index returns the index of the version in m.migrations, or -1.
+func (m *Migrator) index(version int) int {
+	for i, mig := range m.migrations {
+		if mig.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// Version returns the last applied version, or 0 for an empty database.
+func (m *Migrator) Version() (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+	var version int
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+// Status returns the state of every migration in the order of versions.
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	err = m.check(applied)
+	if err != nil {
+		return nil, err
+	}
+	var status []Status
+	for _, mig := range m.migrations {
+		status = append(status, Status{Migration: mig, AppliedAt: applied[mig.Version]})
+	}
+	return status, nil
+}
+
+// Up applies the pending migrations up to the target version, or all of them
+// if the target is 0, and returns the applied ones.
+func (m *Migrator) Up(target int) ([]Migration, error) {
+	if target == 0 {
+		target = m.Latest()
+	}
+	if target != 0 && m.index(target) < 0 {
+		return nil, fmt.Errorf("%w %d", ErrUnknownVersion, target)
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	err = m.check(applied)
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for _, mig := range m.migrations {
+		if mig.Version > target {
+			break
+		}
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+		err = m.run(mig.Up, `INSERT INTO schema_migrations(version, name, applied_at) VALUES ($1, $2, $3)`,
+			mig.Version, mig.Name, time.Now().Unix())
+		if err != nil {
+			return done, fmt.Errorf("migrate: up %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// Down reverts the given number of the last applied migrations and returns
+// the reverted ones.
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	err = m.check(applied)
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		mig := m.migrations[i]
+		if _, ok := applied[mig.Version]; !ok {
+			continue
+		}
+		err = m.run(mig.Down, `DELETE FROM schema_migrations WHERE version = $1`, mig.Version)
+		if err != nil {
+			return done, fmt.Errorf("migrate: down %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// run executes the migration statements and the record statement in a
+// transaction.
+func (m *Migrator) run(statements, record string, args ...interface{}) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	_, err = tx.Exec(statements)
+	if err != nil {
+		return err
+	}
+	r, err := tx.Exec(record, args...)
+	if err != nil {
+		return err
+	}
+	// No row means a concurrent migrator has reverted the version already.
+	n, err := r.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return errors.New("schema_migrations changed concurrently")
+	}
+	return tx.Commit()
+}
+
+// Command runs the migrate command of a server with the arguments following
+// "migrate" and reports to w:
+//
+//	up [version]   apply the pending migrations up to the version or all of them
+//	down [steps]   revert the last applied migration or the given number of them
+//	status         list the migrations with their application times
+func Command(m *Migrator, args []string, w io.Writer) error {
+	usage := errors.New("usage: migrate [up [version] | down [steps] | status]")
+	cmd := "up"
+	if len(args) > 0 {
+		cmd = args[0]
+	}
+	var n int
+	switch len(args) {
+	case 0, 1:
+	case 2:
+		var err error
+		n, err = strconv.Atoi(args[1])
+		if err != nil || n <= 0 || cmd == "status" {
+			return usage
+		}
+	default:
+		return usage
+	}
+	switch cmd {
+	case "up":
+		done, err := m.Up(n)
+		for _, mig := range done {
+			fmt.Fprintf(w, "Applied %04d_%s\n", mig.Version, mig.Name)
+		}
+		if err == nil && len(done) == 0 {
+			fmt.Fprintln(w, "The schema is up to date")
+		}
+		return err
+	case "down":
+		if n == 0 {
+			n = 1
+		}
+		done, err := m.Down(n)
+		for _, mig := range done {
+			fmt.Fprintf(w, "Reverted %04d_%s\n", mig.Version, mig.Name)
+		}
+		return err
+	case "status":
+		status, err := m.Status()
+		if err != nil {
+			return err
+		}
+		for _, s := range status {
+			applied := "pending"
+			if s.AppliedAt != 0 {
+				applied = "applied " + time.Unix(s.AppliedAt, 0).UTC().Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d_%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return nil
+	}
+	return usage
+}
@@ -0,0 +1,197 @@
+package migrate
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	_ "modernc.org/sqlite"
+)
+
+var files = fstest.MapFS{
+	"0001_create_a.up.sql":   {Data: []byte("CREATE TABLE a (id INTEGER);")},
+	"0001_create_a.down.sql": {Data: []byte("DROP TABLE a;")},
+	"0002_create_b.up.sql":   {Data: []byte("CREATE TABLE b (id INTEGER); CREATE TABLE c (id INTEGER);")},
+	"0002_create_b.down.sql": {Data: []byte("DROP TABLE c; DROP TABLE b;")},
+	"0003_broken.up.sql":     {Data: []byte("CREATE TABLE d (id INTEGER); CREATE TABLE a (id INTEGER);")},
+	"0003_broken.down.sql":   {Data: []byte("DROP TABLE d;")},
+	"README.md":              {Data: []byte("not a migration")},
+}
+
+// open returns a migrator of a new in-memory database.
+func open(t *testing.T, fsys fstest.MapFS) *Migrator {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	m, err := New(db, fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	return m
+}
+
+// tables returns the names of the tables in the database.
+func tables(t *testing.T, m *Migrator) string {
+	t.Helper()
+	rows, err := m.db.Query(`SELECT name FROM sqlite_master WHERE type = 'table' ORDER BY name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	return strings.Join(names, " ")
+}
+
+func versions(migrations []Migration) []int {
+	var v []int
+	for _, m := range migrations {
+		v = append(v, m.Version)
+	}
+	return v
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(files)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := versions(migrations); len(got) != 3 || got[0] != 1 || got[1] != 2 || got[2] != 3 {
+		t.Fatalf("Load() versions = %v, want [1 2 3]", got)
+	}
+	if migrations[1].Name != "create_b" || !strings.HasPrefix(migrations[1].Down, "DROP TABLE c") {
+		t.Errorf("Load()[1] = %+v", migrations[1])
+	}
+
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{"missing down", fstest.MapFS{"0001_a.up.sql": {Data: []byte("SELECT 1;")}}},
+		{"different names", fstest.MapFS{
+			"0001_a.up.sql":   {Data: []byte("SELECT 1;")},
+			"0001_b.down.sql": {Data: []byte("SELECT 1;")},
+		}},
+		{"zero version", fstest.MapFS{
+			"0000_a.up.sql":   {Data: []byte("SELECT 1;")},
+			"0000_a.down.sql": {Data: []byte("SELECT 1;")},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Load(tt.fsys); err == nil {
+				t.Error("Load() succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestUpDown(t *testing.T) {
+	m := open(t, files)
+
+	done, err := m.Up(2)
+	if err != nil {
+		t.Fatalf("Up(2) error = %v", err)
+	}
+	if got := versions(done); len(got) != 2 {
+		t.Errorf("Up(2) applied %v, want [1 2]", got)
+	}
+	if got, want := tables(t, m), "a b c schema_migrations"; got != want {
+		t.Errorf("tables = %q, want %q", got, want)
+	}
+	if v, err := m.Version(); err != nil || v != 2 {
+		t.Errorf("Version() = %d, %v; want 2", v, err)
+	}
+
+	// The broken migration leaves neither tables nor a record.
+	done, err = m.Up(0)
+	if err == nil {
+		t.Fatal("Up() of a broken migration succeeded, want an error")
+	}
+	if len(done) != 0 {
+		t.Errorf("Up() applied %v, want none", versions(done))
+	}
+	if got, want := tables(t, m), "a b c schema_migrations"; got != want {
+		t.Errorf("tables after a failure = %q, want %q", got, want)
+	}
+	status, err := m.Status()
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if len(status) != 3 || status[0].AppliedAt == 0 || status[1].AppliedAt == 0 || status[2].AppliedAt != 0 {
+		t.Errorf("Status() = %+v, want 1 and 2 applied", status)
+	}
+
+	done, err = m.Down(5)
+	if err != nil {
+		t.Fatalf("Down(5) error = %v", err)
+	}
+	if got := versions(done); len(got) != 2 || got[0] != 2 || got[1] != 1 {
+		t.Errorf("Down(5) reverted %v, want [2 1]", got)
+	}
+	if got, want := tables(t, m), "schema_migrations"; got != want {
+		t.Errorf("tables = %q, want %q", got, want)
+	}
+	if v, err := m.Version(); err != nil || v != 0 {
+		t.Errorf("Version() = %d, %v; want 0", v, err)
+	}
+}
+
+func TestUnknownVersion(t *testing.T) {
+	m := open(t, files)
+	if _, err := m.Up(7); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("Up(7) error = %v, want ErrUnknownVersion", err)
+	}
+	if _, err := m.Up(1); err != nil {
+		t.Fatal(err)
+	}
+	// The database was migrated by a newer binary.
+	older := &Migrator{db: m.db}
+	if _, err := older.Up(0); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("Up() with an unknown applied version error = %v, want ErrUnknownVersion", err)
+	}
+	if _, err := older.Down(1); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("Down() with an unknown applied version error = %v, want ErrUnknownVersion", err)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	m := open(t, files)
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"up", "2"}, "Applied 0001_create_a\nApplied 0002_create_b\n"},
+		{[]string{"up", "2"}, "The schema is up to date\n"},
+		{[]string{"down"}, "Reverted 0002_create_b\n"},
+		{[]string{"status"}, "0001_create_a\tapplied "},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		err := Command(m, tt.args, &out)
+		if err != nil {
+			t.Fatalf("Command(%q) error = %v", tt.args, err)
+		}
+		if !strings.HasPrefix(out.String(), tt.want) {
+			t.Errorf("Command(%q) output = %q, want %q", tt.args, out.String(), tt.want)
+		}
+	}
+	for _, args := range [][]string{{"sideways"}, {"down", "x"}, {"down", "0"}, {"status", "1"}, {"up", "1", "2"}} {
+		if err := Command(m, args, &bytes.Buffer{}); err == nil {
+			t.Errorf("Command(%q) succeeded, want the usage", args)
+		}
+	}
+}
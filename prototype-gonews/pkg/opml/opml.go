@@ -0,0 +1,203 @@
+// Package opml imports and exports feed subscriptions in OPML 2.0 format.
+package opml
+
+import (
+	newsStorage "GoNews/news/pkg/storage"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrInvalid is returned when the document is not a valid OPML document.
+var ErrInvalid = errors.New("opml: invalid document")
+
+// OPML is the root element of an OPML document.
+type OPML struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    Head     `xml:"head"`
+	Body    Body     `xml:"body"`
+}
+
+type Head struct {
+	Title       string `xml:"title,omitempty"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+type Body struct {
+	Outlines []Outline `xml:"outline"`
+}
+
+// Outline is either a subscription, if XMLURL is set, or a folder.
+type Outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr,omitempty"`
+	Type     string    `xml:"type,attr,omitempty"`
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string    `xml:"htmlUrl,attr,omitempty"`
+	Category string    `xml:"category,attr,omitempty"`
+	Outlines []Outline `xml:"outline"`
+}
+
+// Decode reads an OPML document and returns the subscriptions it contains.
+// Folders become the category of the feeds inside them.
+func Decode(r io.Reader) ([]newsStorage.Feed, error) {
+	var doc OPML
+	err := xml.NewDecoder(r).Decode(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	var feeds []newsStorage.Feed
+	walk(doc.Body.Outlines, "", &feeds)
+	return feeds, nil
+}
+
+// walk collects the subscriptions of the outlines nested in the folder.
+func walk(outlines []Outline, folder string, feeds *[]newsStorage.Feed) {
+	for _, o := range outlines {
+		if o.XMLURL == "" {
+			walk(o.Outlines, join(folder, outlineTitle(o)), feeds)
+			continue
+		}
+		category := folder
+		if category == "" && o.Category != "" {
+			category = strings.Trim(strings.Split(o.Category, ",")[0], "/ ")
+		}
+		*feeds = append(*feeds, newsStorage.Feed{
+			URL:      strings.TrimSpace(o.XMLURL),
+			Title:    outlineTitle(o),
+			Category: category,
+		})
+	}
+}
+
+func outlineTitle(o Outline) string {
+	if o.Title != "" {
+		return strings.TrimSpace(o.Title)
+	}
+	return strings.TrimSpace(o.Text)
+}
+
+func join(folder, name string) string {
+	if folder == "" {
+		return name
+	}
+	if name == "" {
+		return folder
+	}
+	return folder + "/" + name
+}
+
+// Encode writes the subscriptions as an OPML document, grouping them into
+// folders by category.
+func Encode(w io.Writer, title string, feeds []newsStorage.Feed) error {
+	doc := OPML{
+		Version: "2.0",
+		Head: Head{
+			Title:       title,
+			DateCreated: time.Now().UTC().Format(time.RFC1123),
+		},
+	}
+	root := &folder{}
+	for _, f := range feeds {
+		dir := root
+		if f.Category != "" {
+			for _, name := range strings.Split(f.Category, "/") {
+				dir = dir.child(name)
+			}
+		}
+		text := f.Title
+		if text == "" {
+			text = f.URL
+		}
+		dir.feeds = append(dir.feeds, Outline{
+			Text:   text,
+			Title:  f.Title,
+			Type:   "rss",
+			XMLURL: f.URL,
+		})
+	}
+	doc.Body.Outlines = root.outlines()
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(doc)
+}
+
+// folder is a node of the category tree built while encoding.
+type folder struct {
+	name     string
+	children []*folder
+	feeds    []Outline
+}
+
+func (f *folder) child(name string) *folder {
+	for _, c := range f.children {
+		if c.name == name {
+			return c
+		}
+	}
+	c := &folder{name: name}
+	f.children = append(f.children, c)
+	return c
+}
+
+// outlines returns the subfolders ordered by name followed by the feeds.
+func (f *folder) outlines() []Outline {
+	sort.Slice(f.children, func(i, j int) bool { return f.children[i].name < f.children[j].name })
+	var res []Outline
+	for _, c := range f.children {
+		res = append(res, Outline{Text: c.name, Title: c.name, Outlines: c.outlines()})
+	}
+	return append(res, f.feeds...)
+}
+
+// Import adds the subscriptions of the OPML document to the storage, skipping
+// the feeds that are already present or fail newsStorage.ValidateFeed, and
+// returns the number of added feeds.
+func Import(store newsStorage.FeedsInterface, r io.Reader) (int, error) {
+	feeds, err := Decode(r)
+	if err != nil {
+		return 0, err
+	}
+	existing, err := store.Feeds()
+	if err != nil {
+		return 0, err
+	}
+	known := make(map[string]bool)
+	for _, f := range existing {
+		known[f.URL] = true
+	}
+	var added int
+	for _, f := range feeds {
+		if known[f.URL] || newsStorage.ValidateFeed(f) != nil {
+			continue
+		}
+		_, err = store.AddFeed(f)
+		if errors.Is(err, newsStorage.ErrFeedExists) {
+			continue
+		}
+		if err != nil {
+			return added, err
+		}
+		known[f.URL] = true
+		added++
+	}
+	return added, nil
+}
+
+// Export writes all subscriptions of the storage as an OPML document.
+func Export(store newsStorage.FeedsInterface, w io.Writer) error {
+	feeds, err := store.Feeds()
+	if err != nil {
+		return err
+	}
+	return Encode(w, "GoNews subscriptions", feeds)
+}
@@ -0,0 +1,122 @@
+package opml
+
+import (
+	newsStorage "GoNews/news/pkg/storage"
+	"GoNews/news/pkg/storage/memdb"
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const nested = `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="2.0">
+  <head><title>Subscriptions</title></head>
+  <body>
+    <outline text="Top level" type="rss" xmlUrl=" https://top.example.com/rss "/>
+    <outline text="Tech">
+      <outline text="Go blog" title="The Go Blog" type="rss" xmlUrl="https://go.dev/blog/feed.atom"/>
+      <outline text="Hardware">
+        <outline text="Chips" type="rss" xmlUrl="https://chips.example.com/rss"/>
+      </outline>
+    </outline>
+    <outline text="Empty folder"/>
+    <outline text="Tagged" type="rss" xmlUrl="https://tagged.example.com/rss" category="/World/Europe,/Politics"/>
+  </body>
+</opml>`
+
+func TestDecode(t *testing.T) {
+	got, err := Decode(strings.NewReader(nested))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	want := []newsStorage.Feed{
+		{URL: "https://top.example.com/rss", Title: "Top level"},
+		{URL: "https://go.dev/blog/feed.atom", Title: "The Go Blog", Category: "Tech"},
+		{URL: "https://chips.example.com/rss", Title: "Chips", Category: "Tech/Hardware"},
+		{URL: "https://tagged.example.com/rss", Title: "Tagged", Category: "World/Europe"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	for _, doc := range []string{
+		"",
+		"not xml at all",
+		`<opml version="2.0"><body><outline text="x">`,
+		`<rss version="2.0"><channel></channel></rss>`,
+	} {
+		if _, err := Decode(strings.NewReader(doc)); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Decode(%q) error = %v, want %v", doc, err, ErrInvalid)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	src := memdb.New()
+	feeds := []newsStorage.Feed{
+		{URL: "https://a.example.com/rss", Title: "A"},
+		{URL: "https://b.example.com/rss", Title: "B", Category: "Tech"},
+		{URL: "https://c.example.com/rss", Title: "C", Category: "Tech/Hardware"},
+		{URL: "https://d.example.com/rss", Title: "D", Category: "World"},
+	}
+	for _, f := range feeds {
+		if _, err := src.AddFeed(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := Export(src, &buf); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	dst := memdb.New()
+	n, err := Import(dst, &buf)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if n != len(feeds) {
+		t.Errorf("Import() = %d, want %d", n, len(feeds))
+	}
+	got, err := dst.Feeds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	byURL := make(map[string]newsStorage.Feed)
+	for _, f := range got {
+		f.ID = 0
+		byURL[f.URL] = f
+	}
+	for _, f := range feeds {
+		if byURL[f.URL] != f {
+			t.Errorf("imported %s = %+v, want %+v", f.URL, byURL[f.URL], f)
+		}
+	}
+}
+
+func TestImportSkips(t *testing.T) {
+	db := memdb.New()
+	if _, err := db.AddFeed(newsStorage.Feed{URL: "https://a.example.com/rss"}); err != nil {
+		t.Fatal(err)
+	}
+	doc := `<opml version="2.0"><body>
+		<outline text="stored" xmlUrl="https://a.example.com/rss"/>
+		<outline text="new" xmlUrl="https://b.example.com/rss"/>
+		<outline text="repeated" xmlUrl="https://b.example.com/rss"/>
+		<outline text="not http" xmlUrl="ftp://c.example.com/rss"/>
+		<outline text="relative" xmlUrl="/rss"/>
+	</body></opml>`
+	n, err := Import(db, strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Import() = %d, want 1", n)
+	}
+	feeds, _ := db.Feeds()
+	if len(feeds) != 2 || feeds[1].URL != "https://b.example.com/rss" {
+		t.Errorf("Feeds() = %+v, want the stored feed and https://b.example.com/rss", feeds)
+	}
+}
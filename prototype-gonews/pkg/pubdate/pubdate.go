@@ -0,0 +1,233 @@
+// Package pubdate parses publication dates in the many formats found in feeds.
+package pubdate
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrFormat is returned when the date is in none of the supported formats.
+var ErrFormat = errors.New("pubdate: unsupported date format")
+
+// Layouts of ISO 8601 dates. Dates without a zone are taken as UTC.
+var isoLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// Layouts of RFC 822 and RFC 1123 dates after normalization: weekday and
+// commas removed, month names translated to English, zone names replaced with
+// offsets. Dates without a zone are taken as UTC.
+var rfcLayouts = []string{
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04 -0700",
+	"2 Jan 06 15:04:05 -0700",
+	"2 Jan 06 15:04 -0700",
+	"2 Jan 2006 15:04:05",
+	"2 Jan 2006 15:04",
+	"2 Jan 2006",
+	"Jan 2 2006 15:04:05 -0700",
+	"Jan 2 2006 15:04 -0700",
+	"Jan 2 2006 15:04:05",
+	"Jan 2 2006 15:04",
+	"Jan 2 2006",
+	"Jan 2 15:04:05 2006",
+}
+
+// Offsets of the zone names used in feeds, in minutes east of UTC.
+var zones = map[string]int{
+	"UT": 0, "UTC": 0, "GMT": 0, "Z": 0, "WET": 0,
+	"EST": -5 * 60, "EDT": -4 * 60,
+	"CST": -6 * 60, "CDT": -5 * 60,
+	"MST": -7 * 60, "MDT": -6 * 60,
+	"PST": -8 * 60, "PDT": -7 * 60,
+	"AKST": -9 * 60, "AKDT": -8 * 60,
+	"HST": -10 * 60,
+	"BST": 60, "IST": 5*60 + 30, "WEST": 60,
+	"CET": 60, "CEST": 2 * 60, "MET": 60, "MEST": 2 * 60,
+	"EET": 2 * 60, "EEST": 3 * 60,
+	"MSK": 3 * 60, "MSD": 4 * 60,
+	"SAMT": 4 * 60, "YEKT": 5 * 60, "OMST": 6 * 60, "KRAT": 7 * 60,
+	"IRKT": 8 * 60, "YAKT": 9 * 60, "VLAT": 10 * 60, "MAGT": 11 * 60,
+	"PKT": 5 * 60, "HKT": 8 * 60, "SGT": 8 * 60, "JST": 9 * 60, "KST": 9 * 60,
+	"AWST": 8 * 60, "ACST": 9*60 + 30, "AEST": 10 * 60, "AEDT": 11 * 60,
+	"NZST": 12 * 60, "NZDT": 13 * 60,
+}
+
+// English abbreviations of localized month names and their abbreviations.
+var months = map[string]string{
+	// English
+	"jan": "Jan", "january": "Jan", "feb": "Feb", "february": "Feb",
+	"mar": "Mar", "march": "Mar", "apr": "Apr", "april": "Apr", "may": "May",
+	"jun": "Jun", "june": "Jun", "jul": "Jul", "july": "Jul",
+	"aug": "Aug", "august": "Aug", "sep": "Sep", "sept": "Sep", "september": "Sep",
+	"oct": "Oct", "october": "Oct", "nov": "Nov", "november": "Nov",
+	"dec": "Dec", "december": "Dec",
+	// Russian, nominative and genitive
+	"янв": "Jan", "январь": "Jan", "января": "Jan",
+	"фев": "Feb", "февр": "Feb", "февраль": "Feb", "февраля": "Feb",
+	"мар": "Mar", "март": "Mar", "марта": "Mar",
+	"апр": "Apr", "апрель": "Apr", "апреля": "Apr",
+	"май": "May", "мая": "May",
+	"июн": "Jun", "июнь": "Jun", "июня": "Jun",
+	"июл": "Jul", "июль": "Jul", "июля": "Jul",
+	"авг": "Aug", "август": "Aug", "августа": "Aug",
+	"сен": "Sep", "сент": "Sep", "сентябрь": "Sep", "сентября": "Sep",
+	"окт": "Oct", "октябрь": "Oct", "октября": "Oct",
+	"ноя": "Nov", "нояб": "Nov", "ноябрь": "Nov", "ноября": "Nov",
+	"дек": "Dec", "декабрь": "Dec", "декабря": "Dec",
+	// German
+	"januar": "Jan", "jän": "Jan", "jänner": "Jan", "februar": "Feb",
+	"mär": "Mar", "märz": "Mar", "mai": "May", "juni": "Jun", "juli": "Jul",
+	"okt": "Oct", "oktober": "Oct", "dez": "Dec", "dezember": "Dec",
+	// French
+	"janv": "Jan", "janvier": "Jan", "févr": "Feb", "février": "Feb",
+	"mars": "Mar", "avr": "Apr", "avril": "Apr", "juin": "Jun",
+	"juil": "Jul", "juillet": "Jul", "août": "Aug", "septembre": "Sep",
+	"octobre": "Oct", "novembre": "Nov", "déc": "Dec", "décembre": "Dec",
+	// Spanish
+	"ene": "Jan", "enero": "Jan", "febrero": "Feb", "marzo": "Mar",
+	"abr": "Apr", "abril": "Apr", "mayo": "May", "junio": "Jun", "julio": "Jul",
+	"ago": "Aug", "agosto": "Aug", "septiembre": "Sep", "octubre": "Oct",
+	"noviembre": "Nov", "dic": "Dec", "diciembre": "Dec",
+}
+
+// Weekday names and abbreviations which may open a date.
+var weekdays = map[string]bool{
+	"mon": true, "tue": true, "tues": true, "wed": true, "thu": true, "thur": true, "thurs": true,
+	"fri": true, "sat": true, "sun": true,
+	"monday": true, "tuesday": true, "wednesday": true, "thursday": true,
+	"friday": true, "saturday": true, "sunday": true,
+	"пн": true, "вт": true, "ср": true, "чт": true, "пт": true, "сб": true, "вс": true,
+	"понедельник": true, "вторник": true, "среда": true, "четверг": true,
+	"пятница": true, "суббота": true, "воскресенье": true,
+	"mo": true, "di": true, "mi": true, "do": true, "fr": true, "sa": true, "so": true,
+	"montag": true, "dienstag": true, "mittwoch": true, "donnerstag": true,
+	"freitag": true, "samstag": true, "sonntag": true,
+	"lun": true, "mar": true, "mer": true, "jeu": true, "ven": true, "sam": true, "dim": true,
+	"lundi": true, "mardi": true, "mercredi": true, "jeudi": true,
+	"vendredi": true, "samedi": true, "dimanche": true,
+	"lunes": true, "martes": true, "miércoles": true, "jueves": true,
+	"viernes": true, "sábado": true, "domingo": true,
+}
+
+// Filler words written between the parts of localized dates.
+var fillers = map[string]bool{
+	"г": true, "года": true, "в": true, "um": true, "à": true, "de": true, "at": true,
+}
+
+// Parse parses a publication date.
+func Parse(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, ErrFormat
+	}
+	if isISO(s) {
+		for _, layout := range isoLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, ErrFormat
+	}
+	s = normalize(s)
+	for _, layout := range rfcLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, ErrFormat
+}
+
+// ParseOr parses a publication date, falling back to the given time, usually
+// the fetch time. The flag reports whether the fallback was used.
+func ParseOr(s string, fallback time.Time) (t time.Time, estimated bool) {
+	t, err := Parse(s)
+	if err != nil {
+		return fallback, true
+	}
+	return t, false
+}
+
+// isISO reports whether the date starts with a YYYY-MM-DD date.
+func isISO(s string) bool {
+	if len(s) < 10 || s[4] != '-' || s[7] != '-' {
+		return false
+	}
+	for _, i := range []int{0, 1, 2, 3, 5, 6, 8, 9} {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// normalize brings an RFC 822-like date to one of rfcLayouts.
+func normalize(s string) string {
+	fields := strings.Fields(strings.ReplaceAll(s, ",", " "))
+	res := make([]string, 0, len(fields))
+	for i, f := range fields {
+		word := strings.ToLower(strings.TrimSuffix(f, "."))
+		if (i == 0 && isWeekday(fields)) || fillers[word] {
+			continue
+		}
+		if m, ok := months[word]; ok {
+			res = append(res, m)
+			continue
+		}
+		if offset, ok := zones[strings.ToUpper(f)]; ok {
+			res = append(res, formatOffset(offset))
+			continue
+		}
+		if isOffset(f) {
+			f = strings.Replace(f, ":", "", 1)
+		}
+		// German dates write the day as "3."
+		res = append(res, strings.TrimSuffix(f, "."))
+	}
+	return strings.Join(res, " ")
+}
+
+// isWeekday reports whether the first field is a weekday. The French "mar"
+// for mardi is also a month, and is taken as the weekday only if a month
+// follows it.
+func isWeekday(fields []string) bool {
+	word := strings.ToLower(strings.TrimSuffix(fields[0], "."))
+	if !weekdays[word] {
+		return false
+	}
+	if _, ok := months[word]; !ok {
+		return true
+	}
+	for _, f := range fields[1:] {
+		if _, ok := months[strings.ToLower(strings.TrimSuffix(f, "."))]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isOffset reports whether the field is a numeric zone offset like +03:00.
+func isOffset(f string) bool {
+	return len(f) == 6 && (f[0] == '+' || f[0] == '-') && f[3] == ':'
+}
+
+// formatOffset formats the offset in minutes as -0700.
+func formatOffset(minutes int) string {
+	sign := byte('+')
+	if minutes < 0 {
+		sign = '-'
+		minutes = -minutes
+	}
+	h, m := minutes/60, minutes%60
+	return string([]byte{sign, byte('0' + h/10), byte('0' + h%10), byte('0' + m/10), byte('0' + m%10)})
+}
@@ -0,0 +1,81 @@
+package pubdate
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	utc := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name string
+		s    string
+		want time.Time
+	}{
+		// RFC 822 and RFC 1123
+		{"RFC 1123", "Mon, 04 Mar 2024 10:00:00 GMT", utc(2024, time.March, 4, 10, 0)},
+		{"numeric zone", "Mon, 4 Mar 2024 13:00:00 +0300", utc(2024, time.March, 4, 10, 0)},
+		{"zone with colon", "Mon, 4 Mar 2024 13:00:00 +03:00", utc(2024, time.March, 4, 10, 0)},
+		{"two-digit year", "Mon, 04 Mar 24 05:00 EST", utc(2024, time.March, 4, 10, 0)},
+		{"no weekday", "4 Mar 2024 03:00:00 PDT", utc(2024, time.March, 4, 10, 0)},
+		{"no seconds", "Mon, 04 Mar 2024 10:00 UT", utc(2024, time.March, 4, 10, 0)},
+		{"full names", "Monday, 4 March 2024 10:00:00 GMT", utc(2024, time.March, 4, 10, 0)},
+		{"no zone", "Mon, 04 Mar 2024 10:00:00", utc(2024, time.March, 4, 10, 0)},
+		{"month first", "Mar 3 2024 10:00:00 GMT", utc(2024, time.March, 3, 10, 0)},
+		{"month first with weekday", "Sun, Mar 3 2024 10:00:00 GMT", utc(2024, time.March, 3, 10, 0)},
+		{"month first without time", "Mar 3, 2024", utc(2024, time.March, 3, 0, 0)},
+		{"ANSI C", "Sun Mar 3 10:00:00 2024", utc(2024, time.March, 3, 10, 0)},
+		// Named zones
+		{"MSK", "Mon, 04 Mar 2024 13:00:00 MSK", utc(2024, time.March, 4, 10, 0)},
+		{"CET", "Mon, 04 Mar 2024 11:00:00 CET", utc(2024, time.March, 4, 10, 0)},
+		{"IST", "Mon, 04 Mar 2024 15:30:00 IST", utc(2024, time.March, 4, 10, 0)},
+		// ISO 8601
+		{"RFC 3339", "2024-03-04T10:00:00Z", utc(2024, time.March, 4, 10, 0)},
+		{"RFC 3339 offset", "2024-03-04T13:00:00+03:00", utc(2024, time.March, 4, 10, 0)},
+		{"ISO without zone", "2024-03-04T10:00:00", utc(2024, time.March, 4, 10, 0)},
+		{"ISO without seconds", "2024-03-04T10:00", utc(2024, time.March, 4, 10, 0)},
+		{"ISO with space", "2024-03-04 10:00:00", utc(2024, time.March, 4, 10, 0)},
+		{"ISO date", "2024-03-04", utc(2024, time.March, 4, 0, 0)},
+		// Localized months
+		{"Russian", "4 марта 2024 г. 10:00", utc(2024, time.March, 4, 10, 0)},
+		{"Russian weekday", "Пн, 04 мар 2024 13:00:00 +0300", utc(2024, time.March, 4, 10, 0)},
+		{"German", "Montag, 4. März 2024 um 10:00", utc(2024, time.March, 4, 10, 0)},
+		{"French", "mar. 5 mars 2024 10:00", utc(2024, time.March, 5, 10, 0)},
+		{"Spanish", "lunes, 4 de marzo de 2024", utc(2024, time.March, 4, 0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.s)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.s, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Parse(%q) = %v, want %v", tt.s, got.UTC(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseError(t *testing.T) {
+	for _, s := range []string{"", "yesterday", "2024-13-45", "Mon, 32 Mar 2024"} {
+		_, err := Parse(s)
+		if !errors.Is(err, ErrFormat) {
+			t.Errorf("Parse(%q) error = %v, want %v", s, err, ErrFormat)
+		}
+	}
+}
+
+func TestParseOr(t *testing.T) {
+	fallback := time.Date(2024, time.March, 4, 10, 0, 0, 0, time.UTC)
+	got, estimated := ParseOr("soon", fallback)
+	if !got.Equal(fallback) || !estimated {
+		t.Errorf("ParseOr() = %v, %v, want %v, true", got, estimated, fallback)
+	}
+	got, estimated = ParseOr("2024-03-05", fallback)
+	if want := fallback.AddDate(0, 0, 1).Truncate(24 * time.Hour); !got.Equal(want) || estimated {
+		t.Errorf("ParseOr() = %v, %v, want %v, false", got, estimated, want)
+	}
+}
@@ -0,0 +1,253 @@
+// Package readability extracts the main text of an article from a web page.
+//
+// The extractor follows the approach of the Readability bookmarklet: every
+// paragraph scores its parent and grandparent elements by its length and the
+// number of commas, class and id names hint at content or boilerplate, and
+// the best scored element is taken as the article body.
+package readability
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+// ErrNoContent is returned when the page has no article-like content.
+var ErrNoContent = errors.New("readability: no article content found")
+
+// Minimal length of the extracted text.
+const minTextLength = 200
+
+// Minimal length of a paragraph taken into account when scoring.
+const minParagraphLength = 25
+
+var (
+	positiveNames = regexp.MustCompile(`(?i)article|body|content|entry|main|page|post|text|story|blog`)
+	negativeNames = regexp.MustCompile(`(?i)comment|meta|footer|footnote|sidebar|share|social|promo|related|recommend|nav|menu|banner|sponsor|advert|\bads?\b|popup|subscribe|newsletter|cookie|breadcrumb|caption`)
+)
+
+// Elements removed before scoring together with their content.
+var removedTags = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Iframe: true,
+	atom.Form: true, atom.Nav: true, atom.Header: true, atom.Footer: true,
+	atom.Aside: true, atom.Button: true, atom.Svg: true, atom.Template: true,
+	atom.Select: true, atom.Input: true, atom.Textarea: true,
+}
+
+// Elements whose text forms the paragraphs of the article.
+var paragraphTags = map[atom.Atom]bool{
+	atom.P: true, atom.Pre: true, atom.Blockquote: true, atom.Li: true,
+	atom.H2: true, atom.H3: true, atom.H4: true,
+}
+
+// Article is the main content of a page.
+type Article struct {
+	Title string // page title
+	Text  string // article text, paragraphs separated by line breaks
+}
+
+// Extract returns the main content of the HTML page. The encoding of the page
+// is detected from the content type, the byte order mark or the <meta> tags.
+func Extract(page []byte, contentType string) (Article, error) {
+	r, err := charset.NewReader(bytes.NewReader(page), contentType)
+	if err != nil {
+		return Article{}, err
+	}
+	doc, err := html.Parse(r)
+	if err != nil {
+		return Article{}, err
+	}
+	var a Article
+	a.Title = title(doc)
+	clean(doc)
+
+	scores := make(map[*html.Node]float64)
+	var order []*html.Node
+	addScore := func(n *html.Node, score float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = classWeight(n)
+			order = append(order, n)
+		}
+		scores[n] += score
+	}
+	forEach(doc, func(n *html.Node) {
+		if n.DataAtom != atom.P && n.DataAtom != atom.Pre {
+			return
+		}
+		text := textOf(n)
+		if len([]rune(text)) < minParagraphLength {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")) + min(float64(len([]rune(text)))/100, 3)
+		addScore(n.Parent, score)
+		if n.Parent != nil {
+			addScore(n.Parent.Parent, score/2)
+		}
+	})
+
+	var best *html.Node
+	var bestScore float64
+	for _, n := range order {
+		// Elements made of links are menus rather than content.
+		score := scores[n] * (1 - linkDensity(n))
+		if best == nil || score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	if best == nil {
+		return a, ErrNoContent
+	}
+	a.Text = paragraphs(best)
+	if len([]rune(a.Text)) < minTextLength {
+		return a, ErrNoContent
+	}
+	return a, nil
+}
+
+// title returns the text of the first <h1> or, if none, of the <title>.
+func title(doc *html.Node) string {
+	var h1, t string
+	forEach(doc, func(n *html.Node) {
+		switch {
+		case n.DataAtom == atom.H1 && h1 == "":
+			h1 = textOf(n)
+		case n.DataAtom == atom.Title && t == "":
+			t = textOf(n)
+		}
+	})
+	if h1 != "" {
+		return h1
+	}
+	return t
+}
+
+// clean removes boilerplate elements and comments from the tree.
+func clean(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.CommentNode || (c.Type == html.ElementNode && (removedTags[c.DataAtom] || isHidden(c))) {
+			n.RemoveChild(c)
+		} else {
+			clean(c)
+		}
+		c = next
+	}
+}
+
+// isHidden reports whether the element is hidden by its hidden or aria-hidden
+// attribute, or is boilerplate by its role.
+func isHidden(n *html.Node) bool {
+	if hasAttr(n, "hidden") || attr(n, "aria-hidden") == "true" {
+		return true
+	}
+	role := attr(n, "role")
+	return role == "navigation" || role == "complementary" || role == "contentinfo"
+}
+
+// classWeight scores the element by its class and id names.
+func classWeight(n *html.Node) float64 {
+	var w float64
+	for _, name := range []string{attr(n, "class"), attr(n, "id")} {
+		if name == "" {
+			continue
+		}
+		if negativeNames.MatchString(name) {
+			w -= 25
+		}
+		if positiveNames.MatchString(name) {
+			w += 25
+		}
+	}
+	if n.DataAtom == atom.Article || n.DataAtom == atom.Main {
+		w += 25
+	}
+	return w
+}
+
+// linkDensity returns the share of the element text inside links.
+func linkDensity(n *html.Node) float64 {
+	total := len(textOf(n))
+	if total == 0 {
+		return 0
+	}
+	var links int
+	forEach(n, func(c *html.Node) {
+		if c.DataAtom == atom.A {
+			links += len(textOf(c))
+		}
+	})
+	return float64(links) / float64(total)
+}
+
+// paragraphs returns the text of the paragraphs of the element.
+func paragraphs(n *html.Node) string {
+	var res []string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			if paragraphTags[c.DataAtom] {
+				if classWeight(c) < 0 {
+					continue
+				}
+				if text := textOf(c); text != "" {
+					res = append(res, text)
+				}
+				continue
+			}
+			if classWeight(c) >= 0 {
+				walk(c)
+			}
+		}
+	}
+	walk(n)
+	return strings.Join(res, "\n")
+}
+
+// textOf returns the text of the node with collapsed whitespace.
+func textOf(n *html.Node) string {
+	var b strings.Builder
+	forEach(n, func(c *html.Node) {
+		if c.Type == html.TextNode {
+			b.WriteString(c.Data)
+		}
+	})
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// forEach calls f for the node and all its descendants in document order.
+func forEach(n *html.Node, f func(*html.Node)) {
+	f(n)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		forEach(c, f)
+	}
+}
+
+// hasAttr reports whether the element has the attribute, which may be empty.
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,98 @@
+package readability
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		contentType string
+		title       string
+		want        []string // paragraphs expected in order
+		notWant     []string // boilerplate which must be dropped
+	}{
+		{
+			name:        "news article",
+			file:        "news.html",
+			contentType: "text/html; charset=utf-8",
+			title:       "City council approves new bike lanes",
+			want: []string{
+				"The city council voted on Tuesday to build twelve miles of protected bike lanes, ending a debate that has lasted for more than three years.",
+				"The plan, which passed seven to two,",
+				"Construction is expected to start in the spring,",
+				"We have waited for this for a long time,",
+				"Opponents argued that the lanes would remove hundreds of parking spaces,",
+			},
+			notWant: []string{"Politics", "Share this story", "worst decision", "sourdough", "Bus fares", "Copyright", "analytics", "newsletter"},
+		},
+		{
+			name:        "blog post",
+			file:        "blog.html",
+			contentType: "text/html",
+			title:       "Notes on error handling",
+			want: []string{
+				"Errors are values,",
+				"A function that can fail returns an error",
+				`return fmt.Errorf("open config: %w", err)`,
+				"Wrapping adds context",
+				"Do not ignore errors silently.",
+			},
+			notWant: []string{"Archive", "Powered by"},
+		},
+		{
+			name:        "charset from meta",
+			file:        "windows-1251.html",
+			contentType: "text/html",
+			title:       "Заголовок статьи",
+			want: []string{
+				"Первый абзац статьи",
+				"Второй абзац статьи",
+				"Третий абзац статьи",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			a, err := Extract(b, tt.contentType)
+			if err != nil {
+				t.Fatalf("Extract() error = %v", err)
+			}
+			if a.Title != tt.title {
+				t.Errorf("Title = %q, want %q", a.Title, tt.title)
+			}
+			rest := a.Text
+			for _, w := range tt.want {
+				i := strings.Index(rest, w)
+				if i < 0 {
+					t.Fatalf("Text = %q, want %q after the previous paragraph", a.Text, w)
+				}
+				rest = rest[i+len(w):]
+			}
+			for _, w := range tt.notWant {
+				if strings.Contains(a.Text, w) {
+					t.Errorf("Text = %q, must not contain %q", a.Text, w)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractNoContent(t *testing.T) {
+	b, err := os.ReadFile(filepath.Join("testdata", "empty.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Extract(b, "text/html"); !errors.Is(err, ErrNoContent) {
+		t.Errorf("Extract() error = %v, want %v", err, ErrNoContent)
+	}
+}
@@ -0,0 +1,172 @@
+package rss
+
+import (
+	"GoNews/news/pkg/pubdate"
+	newsStorage "GoNews/news/pkg/storage"
+	"encoding/xml"
+	"strings"
+	"time"
+)
+
+const atomNS = "http://www.w3.org/2005/Atom"
+
+func init() {
+	Register(Decoder{
+		Name:         "atom",
+		ContentTypes: []string{"application/atom+xml"},
+		Sniff:        sniffRoot("feed", atomNS, ""),
+		Decode:       parseAtom,
+	})
+}
+
+// AtomFeed is the root element of an Atom 1.0 document.
+type AtomFeed struct {
+	XMLName xml.Name     `xml:"feed"`
+	Title   string       `xml:"title"`
+	Authors []AtomPerson `xml:"author"`
+	Links   []AtomLink   `xml:"link"`
+	Entries []AtomEntry  `xml:"entry"`
+}
+
+type AtomEntry struct {
+	ID         string         `xml:"id"`
+	Title      AtomText       `xml:"title"`
+	Summary    AtomText       `xml:"summary"`
+	Content    AtomText       `xml:"content"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Authors    []AtomPerson   `xml:"author"`
+	Categories []AtomCategory `xml:"category"`
+	Links      []AtomLink     `xml:"link"`
+	MediaElements
+}
+
+type AtomCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr"`
+}
+
+type AtomPerson struct {
+	Name string `xml:"name"`
+}
+
+// AtomText is an Atom text construct: plain text, escaped HTML or inline XHTML.
+type AtomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+type AtomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+// String returns the text content of the construct without markup.
+func (t AtomText) String() string {
+	switch t.Type {
+	case "xhtml", "html":
+		return PlainText(t.html())
+	}
+	return strings.TrimSpace(t.Text)
+}
+
+// sanitized returns the content of the construct as sanitized HTML.
+func (t AtomText) sanitized(base string) string {
+	switch t.Type {
+	case "xhtml", "html":
+		return Sanitize(t.html(), base)
+	}
+	return textHTML(t.Text)
+}
+
+// html returns the markup of the construct.
+func (t AtomText) html() string {
+	switch t.Type {
+	case "xhtml":
+		return t.Inner
+	case "html":
+		return t.Text
+	}
+	return ""
+}
+
+// atomEnclosures returns the media of the rel="enclosure" links.
+func atomEnclosures(links []AtomLink) []newsStorage.Media {
+	var enclosures []Enclosure
+	for _, l := range links {
+		if l.Rel == "enclosure" {
+			enclosures = append(enclosures, Enclosure{URL: l.Href, Type: l.Type, Length: l.Length})
+		}
+	}
+	return enclosureMedia(enclosures)
+}
+
+// alternateLink returns the entry's rel="alternate" link. A link without rel is
+// an alternate link by definition.
+func alternateLink(links []AtomLink) string {
+	for _, l := range links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			return l.Href
+		}
+	}
+	return ""
+}
+
+// parseAtom decodes an Atom 1.0 document.
+func parseAtom(b []byte) ([]newsStorage.Post, error) {
+	var f AtomFeed
+	err := xml.Unmarshal(b, &f)
+	if err != nil {
+		return nil, err
+	}
+	fetched := time.Now()
+	var data []newsStorage.Post
+	for _, entry := range f.Entries {
+		var p newsStorage.Post
+		p.Title = entry.Title.String()
+		p.Link = alternateLink(entry.Links)
+		p.Content = entry.Content.String()
+		p.ContentHTML = entry.Content.sanitized(p.Link)
+		if p.Content == "" {
+			p.Content = entry.Summary.String()
+			p.ContentHTML = entry.Summary.sanitized(p.Link)
+		}
+		p.SourceTitle = strings.TrimSpace(f.Title)
+		p.GUID = strings.TrimSpace(entry.ID)
+		var categories []string
+		for _, c := range entry.Categories {
+			if c.Label != "" {
+				categories = append(categories, c.Label)
+			} else {
+				categories = append(categories, c.Term)
+			}
+		}
+		p.Tags = newsStorage.NormalizeTags(categories)
+		p.Media = collectMedia(p.Link,
+			entry.media(),
+			atomEnclosures(entry.Links),
+			htmlImages(entry.Content.html()),
+			htmlImages(entry.Summary.html()),
+		)
+		// Entries without an author inherit the authors of the feed.
+		authors := entry.Authors
+		if len(authors) == 0 {
+			authors = f.Authors
+		}
+		if len(authors) > 0 {
+			p.Author = strings.TrimSpace(authors[0].Name)
+		}
+		date := entry.Published
+		if date == "" {
+			date = entry.Updated
+		}
+		t, estimated := pubdate.ParseOr(date, fetched)
+		p.PubTime = t.Unix()
+		p.PubTimeEstimated = estimated
+		data = append(data, p)
+	}
+	return data, nil
+}
@@ -0,0 +1,87 @@
+package rss
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// xmlEncoding matches the encoding attribute of the XML declaration.
+var xmlEncoding = regexp.MustCompile(`^(\s*<\?xml[^>]*?\sencoding\s*=\s*["'])([^"']*)(["'])`)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+)
+
+// toUTF8 transcodes the document to UTF-8. The charset is taken from the byte
+// order mark, the Content-Type header or the XML declaration, in this order.
+// Since servers often send a default UTF-8 charset for legacy documents, the
+// declaration is trusted instead if the document is not valid UTF-8. The
+// declaration is rewritten to UTF-8, so encoding/xml accepts the result.
+func toUTF8(contentType string, b []byte) ([]byte, error) {
+	var enc encoding.Encoding
+	switch {
+	case bytes.HasPrefix(b, bomUTF8):
+		return utf8Declaration(b[len(bomUTF8):]), nil
+	case bytes.HasPrefix(b, bomUTF16BE), bytes.HasPrefix(b, bomUTF16LE):
+		enc = unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM)
+	default:
+		label := headerCharset(contentType)
+		declared := declaredCharset(b)
+		if label == "" || (isUTF8(label) && !utf8.Valid(b) && declared != "") {
+			label = declared
+		}
+		if label == "" || isUTF8(label) {
+			return utf8Declaration(b), nil
+		}
+		var err error
+		enc, err = htmlindex.Get(label)
+		if err != nil {
+			return nil, fmt.Errorf("rss: unsupported charset %q", label)
+		}
+	}
+	res, err := enc.NewDecoder().Bytes(b)
+	if err != nil {
+		return nil, err
+	}
+	return utf8Declaration(res), nil
+}
+
+// utf8Declaration rewrites the encoding of the XML declaration to UTF-8. A
+// document taken as UTF-8 may still declare another charset, which
+// encoding/xml refuses without a CharsetReader.
+func utf8Declaration(b []byte) []byte {
+	return xmlEncoding.ReplaceAll(b, []byte("${1}UTF-8${3}"))
+}
+
+// headerCharset returns the charset parameter of the Content-Type header.
+func headerCharset(contentType string) string {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(params["charset"])
+}
+
+// declaredCharset returns the encoding from the XML declaration.
+func declaredCharset(b []byte) string {
+	m := xmlEncoding.FindSubmatch(b)
+	if m == nil {
+		return ""
+	}
+	return strings.TrimSpace(string(m[2]))
+}
+
+func isUTF8(label string) bool {
+	label = strings.ToLower(label)
+	return label == "utf-8" || label == "utf8" || label == "us-ascii" || label == "ascii"
+}
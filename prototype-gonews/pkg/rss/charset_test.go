@@ -0,0 +1,79 @@
+package rss
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDecodeCharsets(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		contentType string
+	}{
+		{"windows-1251 declaration", "windows-1251.xml", "application/rss+xml"},
+		{"koi8-r declaration", "koi8-r.xml", "text/xml"},
+		{"declaration over default utf-8 header", "koi8-r.xml", "text/xml; charset=utf-8"},
+		{"header charset", "no-declaration-cp1251.xml", "text/xml; charset=windows-1251"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			posts, err := Decode(tt.contentType, b)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if len(posts) != 1 {
+				t.Fatalf("Decode() returned %d posts, want 1", len(posts))
+			}
+			p := posts[0]
+			if p.Title != "Заголовок статьи" {
+				t.Errorf("Title = %q, want %q", p.Title, "Заголовок статьи")
+			}
+			if p.Content != "Содержание статьи на русском языке" {
+				t.Errorf("Content = %q, want %q", p.Content, "Содержание статьи на русском языке")
+			}
+			if p.SourceTitle != "Новости" {
+				t.Errorf("SourceTitle = %q, want %q", p.SourceTitle, "Новости")
+			}
+		})
+	}
+}
+
+func TestDecodeUTF8WithDeclaredCharset(t *testing.T) {
+	// The header or the byte order mark wins over the declaration, which
+	// must not reach encoding/xml.
+	doc := `<?xml version="1.0" encoding="ISO-8859-1"?>
+<rss version="2.0"><channel><title>News</title><item><title>Plain ASCII title</title></item></channel></rss>`
+	tests := []struct {
+		name        string
+		contentType string
+		b           []byte
+	}{
+		{"utf-8 header", "text/xml; charset=utf-8", []byte(doc)},
+		{"us-ascii header", "application/rss+xml; charset=us-ascii", []byte(doc)},
+		{"byte order mark", "text/xml", append([]byte{0xEF, 0xBB, 0xBF}, doc...)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			posts, err := Decode(tt.contentType, tt.b)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if len(posts) != 1 || posts[0].Title != "Plain ASCII title" {
+				t.Errorf("Decode() = %+v, want one post titled %q", posts, "Plain ASCII title")
+			}
+		})
+	}
+}
+
+func TestDecodeUnsupportedCharset(t *testing.T) {
+	b := []byte(`<?xml version="1.0" encoding="x-unknown"?><rss><channel></channel></rss>`)
+	if _, err := Decode("text/xml", b); err == nil {
+		t.Error("Decode() error = nil, want unsupported charset error")
+	}
+}
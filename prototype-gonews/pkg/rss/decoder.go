@@ -0,0 +1,123 @@
+package rss
+
+import (
+	newsStorage "GoNews/news/pkg/storage"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"mime"
+	"strings"
+	"sync"
+)
+
+// Decoder describes a supported feed format.
+type Decoder struct {
+	Name         string                                     // format name
+	ContentTypes []string                                   // media types the format is served with
+	Sniff        func(b []byte) bool                        // reports whether the document is in this format
+	Decode       func(b []byte) ([]newsStorage.Post, error) // converts the document into publications
+}
+
+var (
+	decodersMu sync.RWMutex
+	decoders   []Decoder
+)
+
+// Register adds a feed format to the registry.
+func Register(d Decoder) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	decoders = append(decoders, d)
+}
+
+// Decode transcodes the document to UTF-8, picks a registered decoder by the
+// content type and the document itself and decodes the document. Sniffing
+// wins over the content type, since publishers often serve feeds as text/xml
+// or text/html.
+func Decode(contentType string, b []byte) ([]newsStorage.Post, error) {
+	b, err := toUTF8(contentType, b)
+	if err != nil {
+		return nil, err
+	}
+	d, ok := lookup(contentType, b)
+	if !ok {
+		return nil, ErrUnknownFormat
+	}
+	return d.Decode(b)
+}
+
+// lookup returns the decoder for the document.
+func lookup(contentType string, b []byte) (Decoder, bool) {
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = ""
+	}
+	var claimed []Decoder
+	for _, d := range decoders {
+		if mediaType != "" && hasType(d.ContentTypes, mediaType) {
+			claimed = append(claimed, d)
+		}
+	}
+	for _, d := range claimed {
+		if d.Sniff(b) {
+			return d, true
+		}
+	}
+	for _, d := range decoders {
+		if d.Sniff(b) {
+			return d, true
+		}
+	}
+	if len(claimed) == 1 {
+		return claimed[0], true
+	}
+	return Decoder{}, false
+}
+
+func hasType(types []string, mediaType string) bool {
+	for _, t := range types {
+		if strings.EqualFold(t, mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// rootElement returns the name of the first element of the XML document.
+func rootElement(b []byte) (xml.Name, error) {
+	d := xml.NewDecoder(bytes.NewReader(b))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return xml.Name{}, ErrUnknownFormat
+		}
+		if err != nil {
+			return xml.Name{}, err
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			return se.Name, nil
+		}
+	}
+}
+
+// sniffRoot returns a sniffing function that matches XML documents by the
+// local name of the root element and, if space is not empty, its namespace.
+func sniffRoot(local string, spaces ...string) func(b []byte) bool {
+	return func(b []byte) bool {
+		root, err := rootElement(b)
+		if err != nil || root.Local != local {
+			return false
+		}
+		if len(spaces) == 0 {
+			return true
+		}
+		for _, s := range spaces {
+			if root.Space == s {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,151 @@
+package rss
+
+import (
+	newsStorage "GoNews/news/pkg/storage"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func unix(s string) int64 {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t.Unix()
+}
+
+func TestDecodeFormats(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		contentType string
+		want        []newsStorage.Post
+	}{
+		{
+			name:        "rss 2.0",
+			file:        "rss.xml",
+			contentType: "application/rss+xml",
+			want: []newsStorage.Post{{
+				Title:       "City council approves bike lanes",
+				Content:     "The council voted seven to two.",
+				ContentHTML: "<p>The council voted <b>seven to two</b>.</p>",
+				PubTime:     unix("2024-01-16T09:30:00Z"),
+				Link:        "https://news.example.com/2024/bike-lanes",
+				SourceTitle: "Example News",
+				Author:      "Jane Doe",
+				GUID:        "news-1001",
+				Tags:        []string{"transport", "city news"},
+				Media: []newsStorage.Media{
+					{URL: "https://cdn.example.com/lanes.mp3", Type: "audio/mpeg", Medium: "audio", Length: 2048},
+					{URL: "https://news.example.com/img/lanes.jpg", Medium: "image", Width: 640, Height: 480},
+				},
+			}},
+		},
+		{
+			name:        "atom",
+			file:        "atom.xml",
+			contentType: "application/atom+xml",
+			want: []newsStorage.Post{
+				{
+					Title:       "Notes on errors",
+					Content:     "Errors are values.",
+					ContentHTML: `<p>Errors are <a href="https://blog.example.com/values" rel="nofollow noopener">values</a>.</p>`,
+					PubTime:     unix("2024-02-01T12:00:00Z"),
+					Link:        "https://blog.example.com/errors",
+					SourceTitle: "Example Blog",
+					Author:      "John Smith",
+					GUID:        "tag:blog.example.com,2024:1",
+					Tags:        []string{"go"},
+					Media: []newsStorage.Media{
+						{URL: "https://blog.example.com/errors.pdf", Type: "application/pdf", Length: 100},
+					},
+				},
+				{
+					// Without published date, content and author of its own.
+					Title:       "Release notes",
+					Content:     "Version 2 is out.",
+					ContentHTML: "<p>Version 2 is out.</p>",
+					PubTime:     unix("2024-02-03T08:00:00+02:00"),
+					Link:        "https://blog.example.com/release",
+					SourceTitle: "Example Blog",
+					Author:      "Blog Team",
+					GUID:        "tag:blog.example.com,2024:2",
+				},
+			},
+		},
+		{
+			name:        "json feed",
+			file:        "jsonfeed.json",
+			contentType: "application/feed+json",
+			want: []newsStorage.Post{
+				{
+					Title:       "First post",
+					Content:     "Hello world",
+					ContentHTML: "<p>Hello <strong>world</strong></p>",
+					PubTime:     unix("2024-03-05T10:15:00+03:00"),
+					Link:        "https://json.example.com/posts/1",
+					SourceTitle: "Example JSON",
+					Author:      "Ann Lee",
+					GUID:        "json-1",
+					Tags:        []string{"news"},
+					Media: []newsStorage.Media{
+						{URL: "https://json.example.com/1.png", Medium: "image"},
+						{URL: "https://json.example.com/1.mp4", Type: "video/mp4", Medium: "video", Length: 4096},
+					},
+				},
+				{
+					Title:       "Second post",
+					Content:     "Line one\nLine two",
+					ContentHTML: "<p>Line one</p><p>Line two</p>",
+					PubTime:     unix("2024-03-06T00:00:00Z"),
+					Link:        "https://json.example.com/posts/2",
+					SourceTitle: "Example JSON",
+					Author:      "Feed Author",
+					GUID:        "json-2",
+				},
+			},
+		},
+		{
+			name:        "rss 1.0",
+			file:        "rdf.xml",
+			contentType: "application/rdf+xml",
+			want: []newsStorage.Post{{
+				Title:       "Library extends opening hours",
+				Content:     "Open until ten on weekdays.",
+				ContentHTML: "Open until <b>ten</b> on weekdays.",
+				PubTime:     unix("2024-04-10T18:00:00+01:00"),
+				Link:        "https://rdf.example.com/items/7",
+				SourceTitle: "Example RDF",
+				Author:      "Maria Rossi",
+				GUID:        "https://rdf.example.com/items/7",
+				Tags:        []string{"culture"},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The format is found by the content type and by sniffing.
+			for _, contentType := range []string{tt.contentType, "text/plain"} {
+				posts, err := Decode(contentType, b)
+				if err != nil {
+					t.Fatalf("Decode(%q) error = %v", contentType, err)
+				}
+				if len(posts) != len(tt.want) {
+					t.Fatalf("Decode(%q) returned %d posts, want %d", contentType, len(posts), len(tt.want))
+				}
+				for i := range posts {
+					if !reflect.DeepEqual(posts[i], tt.want[i]) {
+						t.Errorf("Decode(%q)[%d] =\n%+v\nwant\n%+v", contentType, i, posts[i], tt.want[i])
+					}
+				}
+			}
+		})
+	}
+}
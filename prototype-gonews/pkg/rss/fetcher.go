@@ -0,0 +1,168 @@
+package rss
+
+import (
+	newsStorage "GoNews/news/pkg/storage"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrTooLarge is returned when the response body exceeds the size limit.
+var ErrTooLarge = errors.New("rss: response body too large")
+
+// Default fetcher settings.
+const (
+	DefaultConnectTimeout = 10 * time.Second
+	DefaultReadTimeout    = 30 * time.Second
+	DefaultMaxBodySize    = 10 << 20
+	DefaultMaxRedirects   = 5
+	DefaultUserAgent      = "GoNews/1.0 (+https://github.com/mlnlsTER/GoNews)"
+)
+
+// Options configure a Fetcher. Zero values select the defaults.
+type Options struct {
+	ConnectTimeout time.Duration // timeout of establishing the connection
+	ReadTimeout    time.Duration // timeout of the whole request including reading the body
+	MaxBodySize    int64         // limit of the decompressed body size in bytes
+	MaxRedirects   int           // number of redirects to follow
+	UserAgent      string        // User-Agent header value
+}
+
+// Fetcher downloads feeds over HTTP with timeouts and size limits.
+// It is safe for concurrent use.
+type Fetcher struct {
+	client      *http.Client
+	readTimeout time.Duration
+	maxBodySize int64
+	userAgent   string
+}
+
+// NewFetcher creates a fetcher with the given options.
+func NewFetcher(opts Options) *Fetcher {
+	if opts.ConnectTimeout <= 0 {
+		opts.ConnectTimeout = DefaultConnectTimeout
+	}
+	if opts.ReadTimeout <= 0 {
+		opts.ReadTimeout = DefaultReadTimeout
+	}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = DefaultMaxBodySize
+	}
+	if opts.MaxRedirects <= 0 {
+		opts.MaxRedirects = DefaultMaxRedirects
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = DefaultUserAgent
+	}
+	dialer := &net.Dialer{Timeout: opts.ConnectTimeout, KeepAlive: 30 * time.Second}
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   opts.ConnectTimeout,
+		ResponseHeaderTimeout: opts.ReadTimeout,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		// Compression is handled by the fetcher to limit the decompressed size.
+		DisableCompression: true,
+	}
+	maxRedirects := opts.MaxRedirects
+	client := &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			// via holds the original request and the redirects followed so far.
+			if len(via) > maxRedirects {
+				return fmt.Errorf("rss: stopped after %d redirects", maxRedirects)
+			}
+			return nil
+		},
+	}
+	return &Fetcher{
+		client:      client,
+		readTimeout: opts.ReadTimeout,
+		maxBodySize: opts.MaxBodySize,
+		userAgent:   opts.UserAgent,
+	}
+}
+
+// Fetch downloads and decodes the feed. The request is conditional if v is not
+// empty, and ErrNotModified is returned for an unchanged feed. The validators
+// of the response are returned for the next request; the caller keeps them
+// only once the publications are saved, so a failed save is fetched again.
+func (f *Fetcher) Fetch(ctx context.Context, url string, v Validators) ([]newsStorage.Post, Validators, error) {
+	b, header, err := f.get(ctx, url, v)
+	if err != nil {
+		return nil, Validators{}, err
+	}
+	posts, err := Decode(header.Get("Content-Type"), b)
+	if err != nil {
+		return nil, Validators{}, err
+	}
+	next := Validators{
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+	}
+	return posts, next, nil
+}
+
+// Download returns the body and the content type of the document at url.
+func (f *Fetcher) Download(ctx context.Context, url string) ([]byte, string, error) {
+	b, header, err := f.get(ctx, url, Validators{})
+	if err != nil {
+		return nil, "", err
+	}
+	return b, header.Get("Content-Type"), nil
+}
+
+// get performs a GET request with the validators and reads the body.
+func (f *Fetcher) get(ctx context.Context, url string, v Validators) ([]byte, http.Header, error) {
+	ctx, cancel := context.WithTimeout(ctx, f.readTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("User-Agent", f.userAgent)
+	req.Header.Set("Accept-Encoding", "gzip")
+	if v.ETag != "" {
+		req.Header.Set("If-None-Match", v.ETag)
+	}
+	if v.LastModified != "" {
+		req.Header.Set("If-Modified-Since", v.LastModified)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, nil, ErrNotModified
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("rss: %s: unexpected status %s", url, resp.Status)
+	}
+	var body io.Reader = resp.Body
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer gz.Close()
+		body = gz
+	}
+	// Read one byte past the limit to tell a body of exactly the limit size
+	// from a larger one.
+	b, err := io.ReadAll(io.LimitReader(body, f.maxBodySize+1))
+	if err != nil {
+		return nil, nil, err
+	}
+	if int64(len(b)) > f.maxBodySize {
+		return nil, nil, ErrTooLarge
+	}
+	return b, resp.Header, nil
+}
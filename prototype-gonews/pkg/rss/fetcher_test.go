@@ -0,0 +1,228 @@
+package rss
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const feedDoc = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"><channel><title>News</title>
+<item><title>First</title><link>https://example.com/1</link></item>
+</channel></rss>`
+
+// serveFeed answers with feedDoc and the validators of its only version.
+func serveFeed(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("If-None-Match") == `"v1"` {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/rss+xml")
+	w.Header().Set("ETag", `"v1"`)
+	w.Header().Set("Last-Modified", "Mon, 04 Mar 2024 10:00:00 GMT")
+	w.Write([]byte(feedDoc))
+}
+
+func TestFetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(serveFeed))
+	defer srv.Close()
+	f := NewFetcher(Options{})
+	posts, v, err := f.Fetch(context.Background(), srv.URL, Validators{})
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if len(posts) != 1 || posts[0].Title != "First" {
+		t.Errorf("Fetch() = %+v, want one post titled %q", posts, "First")
+	}
+	want := Validators{ETag: `"v1"`, LastModified: "Mon, 04 Mar 2024 10:00:00 GMT"}
+	if v != want {
+		t.Errorf("Fetch() validators = %+v, want %+v", v, want)
+	}
+	// The validators make the next request conditional.
+	_, v, err = f.Fetch(context.Background(), srv.URL, v)
+	if !errors.Is(err, ErrNotModified) {
+		t.Errorf("Fetch() of an unchanged feed error = %v, want %v", err, ErrNotModified)
+	}
+	if v != (Validators{}) {
+		t.Errorf("Fetch() of an unchanged feed validators = %+v, want none", v)
+	}
+}
+
+func TestFetchHeaders(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		serveFeed(w, r)
+	}))
+	defer srv.Close()
+	tests := []struct {
+		name      string
+		opts      Options
+		v         Validators
+		userAgent string
+	}{
+		{"default", Options{}, Validators{}, DefaultUserAgent},
+		{"custom user agent", Options{UserAgent: "test-agent/1.0"}, Validators{}, "test-agent/1.0"},
+		{"last modified", Options{}, Validators{LastModified: "Mon, 04 Mar 2024 10:00:00 GMT"}, DefaultUserAgent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := NewFetcher(tt.opts).Fetch(context.Background(), srv.URL, tt.v)
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if ua := got.Get("User-Agent"); ua != tt.userAgent {
+				t.Errorf("User-Agent = %q, want %q", ua, tt.userAgent)
+			}
+			if ae := got.Get("Accept-Encoding"); ae != "gzip" {
+				t.Errorf("Accept-Encoding = %q, want %q", ae, "gzip")
+			}
+			if ims := got.Get("If-Modified-Since"); ims != tt.v.LastModified {
+				t.Errorf("If-Modified-Since = %q, want %q", ims, tt.v.LastModified)
+			}
+		})
+	}
+}
+
+// gzipped compresses b.
+func gzipped(t *testing.T, b []byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestFetchGzip(t *testing.T) {
+	body := gzipped(t, []byte(feedDoc))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(body)
+	}))
+	defer srv.Close()
+	posts, _, err := NewFetcher(Options{}).Fetch(context.Background(), srv.URL, Validators{})
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if len(posts) != 1 || posts[0].Title != "First" {
+		t.Errorf("Fetch() = %+v, want one post titled %q", posts, "First")
+	}
+}
+
+func TestFetchMaxBodySize(t *testing.T) {
+	size := int64(len(feedDoc))
+	plain := httptest.NewServer(http.HandlerFunc(serveFeed))
+	defer plain.Close()
+	// The limit applies to the decompressed body.
+	padded := feedDoc + strings.Repeat(" ", 1<<16)
+	compressed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(gzipped(t, []byte(padded)))
+	}))
+	defer compressed.Close()
+	tests := []struct {
+		name  string
+		url   string
+		limit int64
+		want  error
+	}{
+		{"exactly the limit", plain.URL, size, nil},
+		{"one byte over", plain.URL, size - 1, ErrTooLarge},
+		{"decompressed over", compressed.URL, 1 << 12, ErrTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := NewFetcher(Options{MaxBodySize: tt.limit}).Fetch(context.Background(), tt.url, Validators{})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Fetch() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchRedirects(t *testing.T) {
+	// /n redirects to /n-1 and /0 serves the feed.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if n == 0 {
+			serveFeed(w, r)
+			return
+		}
+		http.Redirect(w, r, "/"+strconv.Itoa(n-1), http.StatusFound)
+	}))
+	defer srv.Close()
+	f := NewFetcher(Options{MaxRedirects: 2})
+	if _, _, err := f.Fetch(context.Background(), srv.URL+"/2", Validators{}); err != nil {
+		t.Errorf("Fetch() with 2 redirects error = %v", err)
+	}
+	if _, _, err := f.Fetch(context.Background(), srv.URL+"/3", Validators{}); err == nil {
+		t.Error("Fetch() with 3 redirects error = nil, want the redirect limit")
+	}
+}
+
+func TestFetchReadTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+	start := time.Now()
+	_, _, err := NewFetcher(Options{ReadTimeout: 50 * time.Millisecond}).Fetch(context.Background(), srv.URL, Validators{})
+	if err == nil {
+		t.Fatal("Fetch() of a stalled server error = nil, want a timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Fetch() returned after %v, want about the read timeout", elapsed)
+	}
+}
+
+func TestFetchStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusGone)
+	}))
+	defer srv.Close()
+	_, _, err := NewFetcher(Options{}).Fetch(context.Background(), srv.URL, Validators{})
+	if err == nil || !strings.Contains(err.Error(), "410") {
+		t.Errorf("Fetch() error = %v, want an unexpected status error", err)
+	}
+}
+
+func TestDownload(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Downloads are never conditional.
+		if r.Header.Get("If-None-Match") != "" || r.Header.Get("If-Modified-Since") != "" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<p>page</p>"))
+	}))
+	defer srv.Close()
+	b, contentType, err := NewFetcher(Options{}).Download(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if string(b) != "<p>page</p>" || contentType != "text/html; charset=utf-8" {
+		t.Errorf("Download() = %q, %q, want %q, %q", b, contentType, "<p>page</p>", "text/html; charset=utf-8")
+	}
+}
@@ -0,0 +1,142 @@
+package rss
+
+import (
+	"GoNews/news/pkg/pubdate"
+	newsStorage "GoNews/news/pkg/storage"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+const jsonFeedVersion = "https://jsonfeed.org/version/"
+
+func init() {
+	Register(Decoder{
+		Name:         "jsonfeed",
+		ContentTypes: []string{"application/feed+json", "application/json"},
+		Sniff:        sniffJSONFeed,
+		Decode:       parseJSONFeed,
+	})
+}
+
+// JSONFeed is a JSON Feed 1.x document.
+type JSONFeed struct {
+	Version     string           `json:"version"`
+	Title       string           `json:"title"`
+	HomePageURL string           `json:"home_page_url"`
+	Authors     []JSONFeedAuthor `json:"authors"`
+	Author      *JSONFeedAuthor  `json:"author"` // JSON Feed 1.0
+	Items       []JSONFeedItem   `json:"items"`
+}
+
+type JSONFeedItem struct {
+	ID            string               `json:"id"`
+	URL           string               `json:"url"`
+	Title         string               `json:"title"`
+	ContentHTML   string               `json:"content_html"`
+	ContentText   string               `json:"content_text"`
+	Summary       string               `json:"summary"`
+	DatePublished string               `json:"date_published"`
+	DateModified  string               `json:"date_modified"`
+	Authors       []JSONFeedAuthor     `json:"authors"`
+	Author        *JSONFeedAuthor      `json:"author"` // JSON Feed 1.0
+	Tags          []string             `json:"tags"`
+	Image         string               `json:"image"`
+	BannerImage   string               `json:"banner_image"`
+	Attachments   []JSONFeedAttachment `json:"attachments"`
+}
+
+type JSONFeedAttachment struct {
+	URL         string `json:"url"`
+	MimeType    string `json:"mime_type"`
+	SizeInBytes int64  `json:"size_in_bytes"`
+}
+
+type JSONFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+// authorName returns the name of the first author, preferring the JSON Feed
+// 1.1 authors array over the deprecated author object.
+func authorName(authors []JSONFeedAuthor, author *JSONFeedAuthor) string {
+	if len(authors) > 0 {
+		return authors[0].Name
+	}
+	if author != nil {
+		return author.Name
+	}
+	return ""
+}
+
+// sniffJSONFeed reports whether the document is a JSON object carrying a
+// JSON Feed version URL.
+func sniffJSONFeed(b []byte) bool {
+	s := strings.TrimSpace(string(b))
+	if !strings.HasPrefix(s, "{") {
+		return false
+	}
+	var v struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return false
+	}
+	return strings.HasPrefix(v.Version, jsonFeedVersion)
+}
+
+// parseJSONFeed decodes a JSON Feed document.
+func parseJSONFeed(b []byte) ([]newsStorage.Post, error) {
+	var f JSONFeed
+	err := json.Unmarshal(b, &f)
+	if err != nil {
+		return nil, err
+	}
+	fetched := time.Now()
+	var data []newsStorage.Post
+	for _, item := range f.Items {
+		var p newsStorage.Post
+		p.Title = item.Title
+		switch {
+		case item.ContentHTML != "":
+			p.Content = PlainText(item.ContentHTML)
+			p.ContentHTML = Sanitize(item.ContentHTML, item.URL)
+		case item.ContentText != "":
+			p.Content = item.ContentText
+			p.ContentHTML = textHTML(item.ContentText)
+		default:
+			p.Content = item.Summary
+			p.ContentHTML = textHTML(item.Summary)
+		}
+		p.Link = item.URL
+		p.SourceTitle = f.Title
+		p.GUID = item.ID
+		p.Author = authorName(item.Authors, item.Author)
+		p.Tags = newsStorage.NormalizeTags(item.Tags)
+		images := []newsStorage.Media{
+			{URL: item.Image, Medium: "image"},
+			{URL: item.BannerImage, Medium: "image"},
+		}
+		var attachments []newsStorage.Media
+		for _, a := range item.Attachments {
+			attachments = append(attachments, newsStorage.Media{
+				URL:    a.URL,
+				Type:   a.MimeType,
+				Medium: mediumOf(a.MimeType),
+				Length: a.SizeInBytes,
+			})
+		}
+		p.Media = collectMedia(item.URL, images, attachments, htmlImages(item.ContentHTML))
+		if p.Author == "" {
+			p.Author = authorName(f.Authors, f.Author)
+		}
+		date := item.DatePublished
+		if date == "" {
+			date = item.DateModified
+		}
+		t, estimated := pubdate.ParseOr(date, fetched)
+		p.PubTime = t.Unix()
+		p.PubTimeEstimated = estimated
+		data = append(data, p)
+	}
+	return data, nil
+}
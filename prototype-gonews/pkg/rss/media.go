@@ -0,0 +1,171 @@
+package rss
+
+import (
+	newsStorage "GoNews/news/pkg/storage"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Enclosure is an RSS enclosure.
+type Enclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+// MediaContent is a Media RSS media:content element.
+type MediaContent struct {
+	URL      string `xml:"url,attr"`
+	Type     string `xml:"type,attr"`
+	Medium   string `xml:"medium,attr"`
+	Width    string `xml:"width,attr"`
+	Height   string `xml:"height,attr"`
+	FileSize string `xml:"fileSize,attr"`
+}
+
+// MediaThumbnail is a Media RSS media:thumbnail element.
+type MediaThumbnail struct {
+	URL    string `xml:"url,attr"`
+	Width  string `xml:"width,attr"`
+	Height string `xml:"height,attr"`
+}
+
+// MediaGroup is a Media RSS media:group element.
+type MediaGroup struct {
+	Contents   []MediaContent   `xml:"http://search.yahoo.com/mrss/ content"`
+	Thumbnails []MediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+}
+
+// MediaElements are the Media RSS elements of an item or an entry.
+type MediaElements struct {
+	MediaContents   []MediaContent   `xml:"http://search.yahoo.com/mrss/ content"`
+	MediaThumbnails []MediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	MediaGroups     []MediaGroup     `xml:"http://search.yahoo.com/mrss/ group"`
+}
+
+// imgTag matches inline <img> tags.
+var imgTag = regexp.MustCompile(`(?is)<img\s[^>]*>`)
+
+// imgAttr matches an attribute of a tag.
+var imgAttr = regexp.MustCompile(`(?is)\s(src|width|height)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+
+// media returns the media described by the Media RSS elements.
+func (m MediaElements) media() []newsStorage.Media {
+	contents := m.MediaContents
+	thumbnails := m.MediaThumbnails
+	for _, g := range m.MediaGroups {
+		contents = append(contents, g.Contents...)
+		thumbnails = append(thumbnails, g.Thumbnails...)
+	}
+	var res []newsStorage.Media
+	for _, c := range contents {
+		medium := c.Medium
+		if medium == "" {
+			medium = mediumOf(c.Type)
+		}
+		res = append(res, newsStorage.Media{
+			URL:    c.URL,
+			Type:   c.Type,
+			Medium: medium,
+			Width:  atoi(c.Width),
+			Height: atoi(c.Height),
+			Length: atoi64(c.FileSize),
+		})
+	}
+	for _, t := range thumbnails {
+		res = append(res, newsStorage.Media{
+			URL:    t.URL,
+			Medium: "image",
+			Width:  atoi(t.Width),
+			Height: atoi(t.Height),
+		})
+	}
+	return res
+}
+
+// enclosureMedia returns the media of the enclosures.
+func enclosureMedia(enclosures []Enclosure) []newsStorage.Media {
+	var res []newsStorage.Media
+	for _, e := range enclosures {
+		res = append(res, newsStorage.Media{
+			URL:    e.URL,
+			Type:   e.Type,
+			Medium: mediumOf(e.Type),
+			Length: atoi64(e.Length),
+		})
+	}
+	return res
+}
+
+// htmlImages returns the images of the inline <img> tags.
+func htmlImages(s string) []newsStorage.Media {
+	var res []newsStorage.Media
+	for _, tag := range imgTag.FindAllString(s, -1) {
+		m := newsStorage.Media{Medium: "image"}
+		for _, a := range imgAttr.FindAllStringSubmatch(tag, -1) {
+			v := html.UnescapeString(a[2] + a[3] + a[4])
+			switch strings.ToLower(a[1]) {
+			case "src":
+				m.URL = v
+			case "width":
+				m.Width = atoi(v)
+			case "height":
+				m.Height = atoi(v)
+			}
+		}
+		res = append(res, m)
+	}
+	return res
+}
+
+// collectMedia resolves the media URLs against the publication link and
+// drops empty, non-HTTP and repeated URLs.
+func collectMedia(link string, lists ...[]newsStorage.Media) []newsStorage.Media {
+	base, _ := url.Parse(link)
+	seen := make(map[string]bool)
+	var res []newsStorage.Media
+	for _, list := range lists {
+		for _, m := range list {
+			u, err := url.Parse(strings.TrimSpace(m.URL))
+			if err != nil || m.URL == "" {
+				continue
+			}
+			if base != nil {
+				u = base.ResolveReference(u)
+			}
+			if u.Scheme != "http" && u.Scheme != "https" {
+				continue
+			}
+			m.URL = u.String()
+			if seen[m.URL] {
+				continue
+			}
+			seen[m.URL] = true
+			res = append(res, m)
+		}
+	}
+	return res
+}
+
+// mediumOf returns the medium of the MIME type: image, video or audio.
+func mediumOf(mimeType string) string {
+	for _, medium := range []string{"image", "video", "audio"} {
+		if strings.HasPrefix(mimeType, medium+"/") {
+			return medium
+		}
+	}
+	return ""
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(s))
+	return n
+}
+
+func atoi64(s string) int64 {
+	n, _ := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	return n
+}
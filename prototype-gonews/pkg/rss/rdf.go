@@ -0,0 +1,71 @@
+package rss
+
+import (
+	"GoNews/news/pkg/pubdate"
+	newsStorage "GoNews/news/pkg/storage"
+	"encoding/xml"
+	"time"
+)
+
+const rdfNS = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+
+func init() {
+	Register(Decoder{
+		Name:         "rdf",
+		ContentTypes: []string{"application/rdf+xml"},
+		Sniff:        sniffRoot("RDF", rdfNS),
+		Decode:       parseRDF,
+	})
+}
+
+// RDF is the root element of an RSS 1.0 document. Unlike RSS 2.0, items are
+// siblings of the channel rather than its children.
+type RDF struct {
+	XMLName xml.Name   `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# RDF"`
+	Channel RDFChannel `xml:"channel"`
+	Items   []RDFItem  `xml:"item"`
+}
+
+type RDFChannel struct {
+	Title       string `xml:"title"`
+	Description string `xml:"description"`
+	Link        string `xml:"link"`
+}
+
+type RDFItem struct {
+	Title       string   `xml:"title"`
+	Description string   `xml:"description"`
+	Link        string   `xml:"link"`
+	Date        string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Subjects    []string `xml:"http://purl.org/dc/elements/1.1/ subject"`
+	About       string   `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+}
+
+// parseRDF decodes an RSS 1.0 (RDF) document.
+func parseRDF(b []byte) ([]newsStorage.Post, error) {
+	var f RDF
+	err := xml.Unmarshal(b, &f)
+	if err != nil {
+		return nil, err
+	}
+	fetched := time.Now()
+	var data []newsStorage.Post
+	for _, item := range f.Items {
+		var p newsStorage.Post
+		p.Title = item.Title
+		p.Content = PlainText(item.Description)
+		p.ContentHTML = Sanitize(item.Description, item.Link)
+		p.Link = item.Link
+		p.SourceTitle = f.Channel.Title
+		p.Author = item.Creator
+		p.GUID = item.About
+		p.Tags = newsStorage.NormalizeTags(item.Subjects)
+		p.Media = collectMedia(item.Link, htmlImages(item.Description))
+		t, estimated := pubdate.ParseOr(item.Date, fetched)
+		p.PubTime = t.Unix()
+		p.PubTimeEstimated = estimated
+		data = append(data, p)
+	}
+	return data, nil
+}
@@ -2,16 +2,17 @@
 package rss
 
 import (
-	"GoNews/news/pkg/storage"
+	"GoNews/news/pkg/pubdate"
+	newsStorage "GoNews/news/pkg/storage"
+	"context"
 	"encoding/xml"
-	"io"
-	"net/http"
-	"strings"
+	"errors"
 	"time"
-
-	strip "github.com/grokify/html-strip-tags-go"
 )
 
+// ErrUnknownFormat is returned when no registered decoder accepts the document.
+var ErrUnknownFormat = errors.New("rss: unknown feed format")
+
 type Feed struct {
 	XMLName xml.Name `xml:"rss"`
 	Chanel  Channel  `xml:"channel"`
@@ -25,42 +26,68 @@ type Channel struct {
 }
 
 type Item struct {
-	Title       string `xml:"title"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
-	Link        string `xml:"link"`
+	Title       string      `xml:"title"`
+	Description string      `xml:"description"`
+	PubDate     string      `xml:"pubDate"`
+	Link        string      `xml:"link"`
+	Author      string      `xml:"author"`
+	Creator     string      `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	GUID        string      `xml:"guid"`
+	Encoded     string      `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Categories  []string    `xml:"category"`
+	Enclosures  []Enclosure `xml:"enclosure"`
+	MediaElements
 }
 
+// defaultFetcher is shared by the calls of Parse, so they reuse connections.
+var defaultFetcher = NewFetcher(Options{})
+
 // Parse reads the rss stream and returns an array of decoded news.
 func Parse(url string) ([]newsStorage.Post, error) {
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	b, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
+	posts, _, err := defaultFetcher.Fetch(context.Background(), url, Validators{})
+	return posts, err
+}
+
+func init() {
+	Register(Decoder{
+		Name:         "rss",
+		ContentTypes: []string{"application/rss+xml"},
+		Sniff:        sniffRoot("rss"),
+		Decode:       parseRSS,
+	})
+}
+
+// parseRSS decodes an RSS 2.0 document.
+func parseRSS(b []byte) ([]newsStorage.Post, error) {
 	var f Feed
-	err = xml.Unmarshal(b, &f)
+	err := xml.Unmarshal(b, &f)
 	if err != nil {
 		return nil, err
 	}
+	fetched := time.Now()
 	var data []newsStorage.Post
 	for _, item := range f.Chanel.Items {
 		var p newsStorage.Post
 		p.Title = item.Title
-		p.Content = item.Description
-		p.Content = strip.StripTags(p.Content)
+		p.Content = PlainText(item.Description)
+		p.ContentHTML = Sanitize(item.Description, item.Link)
 		p.Link = item.Link
-		item.PubDate = strings.ReplaceAll(item.PubDate, ",", "")
-		t, err := time.Parse("Mon 2 Jan 2006 15:04:05 -0700", item.PubDate)
-		if err != nil {
-			t, err = time.Parse("Mon 2 Jan 2006 15:04:05 GMT", item.PubDate)
-		}
-		if err == nil {
-			p.PubTime = t.Unix()
+		p.SourceTitle = f.Chanel.Title
+		p.Author = item.Author
+		if p.Author == "" {
+			p.Author = item.Creator
 		}
+		p.GUID = item.GUID
+		p.Tags = newsStorage.NormalizeTags(item.Categories)
+		p.Media = collectMedia(item.Link,
+			item.media(),
+			enclosureMedia(item.Enclosures),
+			htmlImages(item.Description),
+			htmlImages(item.Encoded),
+		)
+		t, estimated := pubdate.ParseOr(item.PubDate, fetched)
+		p.PubTime = t.Unix()
+		p.PubTimeEstimated = estimated
 		data = append(data, p)
 	}
 	return data, nil
@@ -0,0 +1,211 @@
+package rss
+
+import (
+	"html"
+	"net/url"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Elements kept by Sanitize. Other elements are dropped with their text kept.
+var allowedTags = map[atom.Atom]bool{
+	atom.P:          true,
+	atom.Br:         true,
+	atom.A:          true,
+	atom.Ul:         true,
+	atom.Ol:         true,
+	atom.Li:         true,
+	atom.Em:         true,
+	atom.I:          true,
+	atom.Strong:     true,
+	atom.B:          true,
+	atom.Blockquote: true,
+}
+
+// Allowed elements which implicitly close an open paragraph.
+var closesParagraph = map[atom.Atom]bool{
+	atom.P:          true,
+	atom.Ul:         true,
+	atom.Ol:         true,
+	atom.Blockquote: true,
+}
+
+// Elements dropped together with their content. Void elements such as embed
+// have no content or end tag and are dropped like any element not allowed.
+var skippedTags = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Iframe:   true,
+	atom.Object:   true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Svg:      true,
+	atom.Math:     true,
+}
+
+// Elements which start a new line in plain text.
+var blockTags = map[atom.Atom]bool{
+	atom.P: true, atom.Br: true, atom.Div: true, atom.Li: true, atom.Ul: true, atom.Ol: true,
+	atom.Blockquote: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true,
+	atom.H5: true, atom.H6: true, atom.Tr: true, atom.Table: true, atom.Figure: true,
+	atom.Figcaption: true, atom.Pre: true, atom.Hr: true, atom.Section: true, atom.Article: true,
+}
+
+// Sanitize returns the HTML fragment reduced to the allowlisted markup:
+// paragraphs, line breaks, lists, emphasis, quotes and links. Attributes are
+// dropped except for link addresses, which are resolved against base, limited
+// to http, https and mailto, and marked rel="nofollow noopener". Unclosed
+// elements are closed.
+func Sanitize(fragment, base string) string {
+	baseURL, _ := url.Parse(base)
+	var b strings.Builder
+	var open []atom.Atom // allowed elements left open
+	skip := 0            // depth inside skipped elements
+	z := nethtml.NewTokenizer(strings.NewReader(fragment))
+	for {
+		tt := z.Next()
+		if tt == nethtml.ErrorToken {
+			break
+		}
+		tok := z.Token()
+		switch tt {
+		case nethtml.StartTagToken, nethtml.SelfClosingTagToken:
+			if skippedTags[tok.DataAtom] {
+				if tt == nethtml.StartTagToken {
+					skip++
+				}
+				continue
+			}
+			if skip > 0 || !allowedTags[tok.DataAtom] {
+				continue
+			}
+			if tok.DataAtom == atom.Br {
+				b.WriteString("<br>")
+				continue
+			}
+			// A list item closes the previous one and a block closes the
+			// paragraph, as their end tags may be omitted.
+			if n := len(open); n > 0 && (open[n-1] == atom.Li && tok.DataAtom == atom.Li ||
+				open[n-1] == atom.P && closesParagraph[tok.DataAtom]) {
+				b.WriteString("</" + open[n-1].String() + ">")
+				open = open[:n-1]
+			}
+			if tok.DataAtom == atom.A {
+				href, ok := safeLink(tok, baseURL)
+				if !ok {
+					continue
+				}
+				b.WriteString(`<a href="` + html.EscapeString(href) + `" rel="nofollow noopener">`)
+			} else {
+				b.WriteString("<" + tok.Data + ">")
+			}
+			open = append(open, tok.DataAtom)
+		case nethtml.EndTagToken:
+			if skippedTags[tok.DataAtom] {
+				if skip > 0 {
+					skip--
+				}
+				continue
+			}
+			if skip > 0 || !allowedTags[tok.DataAtom] {
+				continue
+			}
+			// Close the elements opened after the matching one.
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != tok.DataAtom {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					b.WriteString("</" + open[j].String() + ">")
+				}
+				open = open[:i]
+				break
+			}
+		case nethtml.TextToken:
+			if skip == 0 {
+				b.WriteString(html.EscapeString(tok.Data))
+			}
+		}
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i].String() + ">")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// safeLink returns the resolved href of the link if its scheme is allowed.
+func safeLink(tok nethtml.Token, base *url.URL) (string, bool) {
+	for _, a := range tok.Attr {
+		if a.Namespace != "" || a.Key != "href" {
+			continue
+		}
+		u, err := url.Parse(strings.TrimSpace(a.Val))
+		if err != nil {
+			return "", false
+		}
+		if base != nil {
+			u = base.ResolveReference(u)
+		}
+		switch u.Scheme {
+		case "http", "https", "mailto":
+			return u.String(), true
+		}
+		return "", false
+	}
+	return "", false
+}
+
+// PlainText returns the text of the HTML fragment. Paragraphs, list items and
+// other blocks are separated by line breaks; other whitespace is collapsed.
+func PlainText(fragment string) string {
+	var lines []string
+	var line strings.Builder
+	flush := func() {
+		if s := strings.Join(strings.Fields(line.String()), " "); s != "" {
+			lines = append(lines, s)
+		}
+		line.Reset()
+	}
+	skip := 0
+	z := nethtml.NewTokenizer(strings.NewReader(fragment))
+	for {
+		tt := z.Next()
+		if tt == nethtml.ErrorToken {
+			break
+		}
+		tok := z.Token()
+		switch tt {
+		case nethtml.StartTagToken, nethtml.SelfClosingTagToken, nethtml.EndTagToken:
+			if skippedTags[tok.DataAtom] {
+				if tt == nethtml.StartTagToken {
+					skip++
+				} else if tt == nethtml.EndTagToken && skip > 0 {
+					skip--
+				}
+				continue
+			}
+			if blockTags[tok.DataAtom] {
+				flush()
+			}
+		case nethtml.TextToken:
+			if skip == 0 {
+				line.WriteString(tok.Data)
+			}
+		}
+	}
+	flush()
+	return strings.Join(lines, "\n")
+}
+
+// textHTML returns plain text as HTML paragraphs, one per line.
+func textHTML(text string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			b.WriteString("<p>" + html.EscapeString(line) + "</p>")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,55 @@
+package rss
+
+import "testing"
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name     string
+		fragment string
+		want     string
+	}{
+		{"allowed markup", "<p>Hello <em>world</em></p>", "<p>Hello <em>world</em></p>"},
+		{"attributes dropped", `<p class="x" onclick="evil()">text</p>`, "<p>text</p>"},
+		{"script dropped with content", "<p>a</p><script>alert(1)</script><p>b</p>", "<p>a</p><p>b</p>"},
+		{"unknown element keeps text", "<div><span>text</span></div>", "text"},
+		{"relative link resolved", `<a href="/about">about</a>`, `<a href="https://example.com/about" rel="nofollow noopener">about</a>`},
+		{"javascript link dropped", `<a href="javascript:alert(1)">click</a>`, "click"},
+		{"unclosed elements closed", "<p><b>bold", "<p><b>bold</b></p>"},
+		{"paragraph closed by list", "<p>intro<ul><li>one<li>two</ul>", "<p>intro</p><ul><li>one</li><li>two</li></ul>"},
+		{"text escaped", "a &lt; b &amp; c", "a &lt; b &amp; c"},
+		{"line break", "one<br/>two", "one<br>two"},
+		{"embed dropped", `<p>Intro</p><embed src="x.swf"><p>Body</p>`, "<p>Intro</p><p>Body</p>"},
+		{"self-closing skipped element", "<p>Intro</p><svg/><p>Body</p>", "<p>Intro</p><p>Body</p>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sanitize(tt.fragment, "https://example.com/news/1"); got != tt.want {
+				t.Errorf("Sanitize(%q) = %q, want %q", tt.fragment, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	tests := []struct {
+		name     string
+		fragment string
+		want     string
+	}{
+		{"paragraphs on lines", "<p>First  paragraph.</p><p>Second\n paragraph.</p>", "First paragraph.\nSecond paragraph."},
+		{"inline markup", "Hello <b>bold</b> <i>world</i>", "Hello bold world"},
+		{"list items", "<ul><li>one</li><li>two</li></ul>", "one\ntwo"},
+		{"script and style dropped", "<style>p{}</style>text<script>x()</script>", "text"},
+		{"entities decoded", "Fish &amp; chips &mdash; 5&nbsp;£", "Fish & chips — 5 £"},
+		{"embed dropped", `<p>Intro</p><embed src="x.swf"><p>Body</p>`, "Intro\nBody"},
+		{"self-closing skipped element", "<p>Intro</p><object/><p>Body</p>", "Intro\nBody"},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PlainText(tt.fragment); got != tt.want {
+				t.Errorf("PlainText(%q) = %q, want %q", tt.fragment, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package rss
+
+import "errors"
+
+// ErrNotModified is returned when the publisher reports that the feed has not
+// changed since the previous request.
+var ErrNotModified = errors.New("rss: feed not modified")
+
+// Validators are the HTTP cache validators of a feed response.
+type Validators struct {
+	ETag         string // ETag response header
+	LastModified string // Last-Modified response header
+}
@@ -0,0 +1,120 @@
+package newsStorage
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidCursor is returned when a cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is a position in the list of publications ordered by publication
+// time or by the order of addition. The page of a cursor starts right after
+// the position, or ends right before it.
+type Cursor struct {
+	Sort    string // SortPubTime or SortID
+	PubTime int64  // publication time of the publication at the position
+	ID      int    // identifier of the publication at the position
+	Before  bool   // the page ends before the position instead of starting after it
+}
+
+// String encodes the cursor as an opaque URL-safe string.
+func (c Cursor) String() string {
+	dir := "a"
+	if c.Before {
+		dir = "b"
+	}
+	sort := c.Sort
+	if sort == "" {
+		sort = SortPubTime
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s:%d:%d", dir, sort, c.PubTime, c.ID)))
+}
+
+// ParseCursor decodes a cursor encoded by String. Cursors without the sort
+// order, issued before the order of addition had cursors, are in the
+// publication time order.
+func ParseCursor(s string) (Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	fields := strings.Split(string(b), ":")
+	if len(fields) == 3 {
+		fields = append(fields[:1], append([]string{SortPubTime}, fields[1:]...)...)
+	}
+	if len(fields) != 4 || (fields[0] != "a" && fields[0] != "b") ||
+		(fields[1] != SortPubTime && fields[1] != SortID) {
+		return Cursor{}, ErrInvalidCursor
+	}
+	c := Cursor{Sort: fields[1], Before: fields[0] == "b"}
+	c.PubTime, err = strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	c.ID, err = strconv.Atoi(fields[3])
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// SortOrder returns the sort order of the query with the defaults applied:
+// the order of the cursor, the relevance for a search and the order of
+// addition otherwise.
+func (q Query) SortOrder() string {
+	switch {
+	case q.Cursor != nil && q.Cursor.Sort == SortID:
+		return SortID
+	case q.Cursor != nil:
+		return SortPubTime
+	case q.Sort != "":
+		return q.Sort
+	case q.Search != "":
+		return SortRelevance
+	}
+	return SortID
+}
+
+// Limit returns the page size of the query: DefaultPageSize if it is not
+// set and at most MaxPageSize.
+func (q Query) Limit() int {
+	switch {
+	case q.PageSize <= 0:
+		return DefaultPageSize
+	case q.PageSize > MaxPageSize:
+		return MaxPageSize
+	}
+	return q.PageSize
+}
+
+// Cursors returns the cursors of the pages next to the page of publications
+// selected by the query. More reports whether there are publications past the
+// page in the direction it was selected in. Cursors are returned for the
+// publication time and addition orders, the relevance order has none.
+func Cursors(page []Post, q Query, more bool) (next, prev string) {
+	sort := q.SortOrder()
+	if len(page) == 0 || (sort != SortPubTime && sort != SortID) {
+		return "", ""
+	}
+	first, last := page[0], page[len(page)-1]
+	hasNext, hasPrev := more, q.Page > 1
+	if q.Cursor != nil {
+		// The publication at the cursor is on the side the page was not
+		// selected in.
+		hasNext, hasPrev = more, true
+		if q.Cursor.Before {
+			hasNext, hasPrev = true, more
+		}
+	}
+	if hasNext {
+		next = Cursor{Sort: sort, PubTime: last.PubTime, ID: last.ID}.String()
+	}
+	if hasPrev {
+		prev = Cursor{Sort: sort, PubTime: first.PubTime, ID: first.ID, Before: true}.String()
+	}
+	return next, prev
+}
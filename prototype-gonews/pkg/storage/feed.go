@@ -0,0 +1,27 @@
+package newsStorage
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// ErrFeedExists is returned when a news source with the same URL is stored.
+var ErrFeedExists = errors.New("feed already exists")
+
+// ErrInvalidFeed is returned by ValidateFeed for a news source which cannot
+// be polled.
+var ErrInvalidFeed = errors.New("invalid feed")
+
+// ValidateFeed checks the user-supplied fields of a news source: the URL must
+// be an absolute http or https address and the poll interval not negative.
+func ValidateFeed(f Feed) error {
+	u, err := url.Parse(f.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: URL %q is not an http or https address", ErrInvalidFeed, f.URL)
+	}
+	if f.Interval < 0 {
+		return fmt.Errorf("%w: negative poll interval %d", ErrInvalidFeed, f.Interval)
+	}
+	return nil
+}
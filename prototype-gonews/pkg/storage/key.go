@@ -0,0 +1,76 @@
+package newsStorage
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Query parameters which only track the reader and do not identify the page.
+var trackingParams = map[string]bool{
+	"fbclid": true,
+	"gclid":  true,
+	"yclid":  true,
+	"mc_cid": true,
+	"mc_eid": true,
+}
+
+// Key returns the identity of the publication used to detect already stored
+// items: the GUID within its source feed if the feed provides one, as a GUID
+// is only unique per feed, or the normalized link otherwise.
+func Key(p Post) string {
+	if guid := strings.TrimSpace(p.GUID); guid != "" {
+		return "guid:" + strconv.Itoa(p.SourceID) + ":" + guid
+	}
+	return NormalizeLink(p.Link)
+}
+
+// NormalizeLink brings equivalent spellings of a link to the same form:
+// lowercases the scheme and host, drops default ports, fragments, tracking
+// parameters and the trailing slash, and sorts the query parameters.
+func NormalizeLink(link string) string {
+	link = strings.TrimSpace(link)
+	u, err := url.Parse(link)
+	if err != nil || u.Host == "" {
+		return link
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if (u.Scheme == "http" && u.Port() == "80") || (u.Scheme == "https" && u.Port() == "443") {
+		u.Host = u.Hostname()
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	if len(u.Path) > 1 {
+		u.Path = strings.TrimRight(u.Path, "/")
+		u.RawPath = ""
+	}
+	q := u.Query()
+	for name := range q {
+		if strings.HasPrefix(name, "utm_") || trackingParams[name] {
+			q.Del(name)
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// NormalizeTag brings a category to the tag form: lowercase with single spaces.
+func NormalizeTag(category string) string {
+	return strings.ToLower(strings.Join(strings.Fields(category), " "))
+}
+
+// NormalizeTags normalizes the categories and drops empty and repeated ones.
+func NormalizeTags(categories []string) []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, c := range categories {
+		t := NormalizeTag(c)
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		tags = append(tags, t)
+	}
+	return tags
+}
@@ -1,16 +1,407 @@
+// Package memdb is an in-memory storage of publications and news sources. It
+// lets the news service run without a database server.
 package memdb
 
 import (
-	"GoNews/news/pkg/storage"
+	"GoNews/news/pkg/dedup"
+	newsStorage "GoNews/news/pkg/storage"
+	"sort"
+	"strings"
+	"sync"
 )
 
-// Data storage.
-type DB []newsStorage.Post
+// Data storage. It is safe for concurrent use.
+type DB struct {
+	mu       sync.RWMutex
+	posts    []newsStorage.Post // in the order of identifiers
+	byKey    map[string]int     // index in posts by newsStorage.Key
+	clusters *dedup.Index
+	lastID   int
+	feeds    []newsStorage.Feed // in the order of identifiers
+	lastFeed int
+}
+
+// Constructor creates a new empty DB object.
+func New() *DB {
+	return &DB{
+		byKey:    make(map[string]int),
+		clusters: dedup.NewIndex(nil),
+	}
+}
+
+// Posts returns a page of publications selected by the query. Publications
+// found by the search have a highlighted snippet. Only the first matching
+// publication of every story is returned.
+func (db *DB) Posts(q newsStorage.Query) ([]newsStorage.Post, newsStorage.Pagination, error) {
+	page := q.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := q.Limit()
+	words := newsStorage.SearchWords(q.Search)
+
+	db.mu.RLock()
+	var posts []newsStorage.Post
+	ranks := make(map[int]int)
+	stories := make(map[int]bool)
+	for _, p := range db.posts {
+		if stories[p.ClusterID] || !match(p, q) {
+			continue
+		}
+		if len(words) > 0 {
+			rank := relevance(p, words)
+			if rank == 0 {
+				continue
+			}
+			ranks[p.ID] = rank
+			p.Snippet = newsStorage.Snippet(p.Content, words)
+		}
+		stories[p.ClusterID] = true
+		posts = append(posts, clone(p))
+	}
+	db.mu.RUnlock()
+
+	sortBy := q.SortOrder()
+	sort.SliceStable(posts, func(i, j int) bool {
+		a, b := posts[i], posts[j]
+		if q.Asc {
+			a, b = b, a
+		}
+		switch {
+		case sortBy == newsStorage.SortPubTime && a.PubTime != b.PubTime:
+			return a.PubTime > b.PubTime
+		case sortBy == newsStorage.SortRelevance && ranks[a.ID] != ranks[b.ID]:
+			return ranks[a.ID] > ranks[b.ID]
+		}
+		return a.ID > b.ID
+	})
+
+	pagination := newsStorage.Pagination{
+		TotalPages:  (len(posts) + pageSize - 1) / pageSize,
+		CurrentPage: page,
+		PageSize:    pageSize,
+		TotalItems:  len(posts),
+	}
+	start := (page - 1) * pageSize
+	if c := q.Cursor; c != nil {
+		pagination.CurrentPage = 0
+		// Index of the first publication after the cursor position.
+		start = sort.Search(len(posts), func(i int) bool {
+			p := posts[i]
+			// In the order of addition only the identifier is compared.
+			pubTime := p.PubTime
+			if sortBy == newsStorage.SortID {
+				pubTime = c.PubTime
+			}
+			if q.Asc {
+				return pubTime > c.PubTime || pubTime == c.PubTime && p.ID > c.ID
+			}
+			return pubTime < c.PubTime || pubTime == c.PubTime && p.ID < c.ID
+		})
+		if c.Before {
+			// The position itself is not on the page.
+			end := start
+			if end > 0 && posts[end-1].ID == c.ID {
+				end--
+			}
+			start = end - pageSize
+			if start < 0 {
+				start = 0
+			}
+			selected := posts[start:end]
+			pagination.NextCursor, pagination.PrevCursor = newsStorage.Cursors(selected, q, start > 0)
+			return selected, pagination, nil
+		}
+	}
+	if start > len(posts) {
+		start = len(posts)
+	}
+	end := start + pageSize
+	if end > len(posts) {
+		end = len(posts)
+	}
+	pagination.NextCursor, pagination.PrevCursor = newsStorage.Cursors(posts[start:end], q, end < len(posts))
+	return posts[start:end], pagination, nil
+}
+
+// AddPosts inserts new publications and updates the edited ones.
+// Publications are matched by newsStorage.Key. A publication without an
+// article keeps the stored one. New publications join the story of their
+// near-duplicates, edited ones stay in their story.
+func (db *DB) AddPosts(posts []newsStorage.Post) (newsStorage.AddResult, error) {
+	var res newsStorage.AddResult
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, post := range posts {
+		key := newsStorage.Key(post)
+		if i, ok := db.byKey[key]; ok {
+			stored := &db.posts[i]
+			if stored.Title == post.Title && stored.ContentHTML == post.ContentHTML &&
+				(post.Article == "" || stored.Article == post.Article) {
+				res.Skipped++
+				continue
+			}
+			stored.Title = post.Title
+			stored.Content = post.Content
+			stored.ContentHTML = post.ContentHTML
+			if post.Article != "" {
+				stored.Article = post.Article
+			}
+			stored.Author = post.Author
+			stored.Link = post.Link
+			stored.Tags = sortedTags(post.Tags)
+			stored.Media = append([]newsStorage.Media(nil), post.Media...)
+			res.Updated++
+			continue
+		}
+		db.lastID++
+		post.ID = db.lastID
+		post.Tags = sortedTags(post.Tags)
+		post.Media = append([]newsStorage.Media(nil), post.Media...)
+		post.Snippet = ""
+		post.ClusterID = db.clusters.Add(post.ID, dedup.Fingerprint(post.Title, post.Content), post.PubTime)
+		db.byKey[key] = len(db.posts)
+		db.posts = append(db.posts, post)
+		res.Inserted++
+	}
+	return res, nil
+}
+
+// PostDetail returns detailed information about a publication by its identifier.
+func (db *DB) PostDetail(id int) (*newsStorage.Post, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	i, ok := db.index(id)
+	if !ok {
+		return nil, newsStorage.ErrNotFound
+	}
+	post := clone(db.posts[i])
+	return &post, nil
+}
+
+// Tags returns the tags with the number of publications marked with them,
+// the most used first.
+func (db *DB) Tags() ([]newsStorage.Tag, error) {
+	db.mu.RLock()
+	counts := make(map[string]int)
+	for _, p := range db.posts {
+		for _, t := range p.Tags {
+			counts[t]++
+		}
+	}
+	db.mu.RUnlock()
+	var tags []newsStorage.Tag
+	for name, count := range counts {
+		tags = append(tags, newsStorage.Tag{Name: name, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Name < tags[j].Name
+	})
+	return tags, nil
+}
+
+// ClusterPosts returns the publications of the stories without their content
+// and media, in the order of addition.
+func (db *DB) ClusterPosts(clusters []int) ([]newsStorage.Post, error) {
+	wanted := make(map[int]bool)
+	for _, c := range clusters {
+		wanted[c] = true
+	}
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	var posts []newsStorage.Post
+	for _, p := range db.posts {
+		if !wanted[p.ClusterID] {
+			continue
+		}
+		posts = append(posts, newsStorage.Post{
+			ID:               p.ID,
+			Title:            p.Title,
+			PubTime:          p.PubTime,
+			PubTimeEstimated: p.PubTimeEstimated,
+			Link:             p.Link,
+			SourceID:         p.SourceID,
+			SourceTitle:      p.SourceTitle,
+			Author:           p.Author,
+			GUID:             p.GUID,
+			ClusterID:        p.ClusterID,
+		})
+	}
+	return posts, nil
+}
+
+// index returns the index of the publication in db.posts.
+func (db *DB) index(id int) (int, bool) {
+	i := sort.Search(len(db.posts), func(i int) bool { return db.posts[i].ID >= id })
+	return i, i < len(db.posts) && db.posts[i].ID == id
+}
+
+// Feeds returns all news sources.
+func (db *DB) Feeds() ([]newsStorage.Feed, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return append([]newsStorage.Feed(nil), db.feeds...), nil
+}
+
+// Feed returns a news source by its identifier.
+func (db *DB) Feed(id int) (*newsStorage.Feed, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	i, ok := db.feedIndex(id)
+	if !ok {
+		return nil, newsStorage.ErrNotFound
+	}
+	f := db.feeds[i]
+	return &f, nil
+}
 
-func (db *DB) Posts() ([]newsStorage.Post, error) {
-	return *db, nil
+// AddFeed creates a new news source and returns its identifier.
+func (db *DB) AddFeed(f newsStorage.Feed) (int, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, stored := range db.feeds {
+		if stored.URL == f.URL {
+			return 0, newsStorage.ErrFeedExists
+		}
+	}
+	db.lastFeed++
+	f.ID = db.lastFeed
+	db.feeds = append(db.feeds, f)
+	return f.ID, nil
 }
-func (db *DB) AddPost(post newsStorage.Post) error {
-	*db = append(*db, post)
+
+// UpdateFeed updates the news source. Its validators are reset if the
+// address changes.
+func (db *DB) UpdateFeed(f newsStorage.Feed) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	i, ok := db.feedIndex(f.ID)
+	if !ok {
+		return newsStorage.ErrNotFound
+	}
+	for _, stored := range db.feeds {
+		if stored.URL == f.URL && stored.ID != f.ID {
+			return newsStorage.ErrFeedExists
+		}
+	}
+	f.ETag, f.LastModified = "", ""
+	if db.feeds[i].URL == f.URL {
+		f.ETag, f.LastModified = db.feeds[i].ETag, db.feeds[i].LastModified
+	}
+	db.feeds[i] = f
+	return nil
+}
+
+// SetFeedValidators stores the HTTP cache validators of the news source.
+func (db *DB) SetFeedValidators(id int, etag, lastModified string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	i, ok := db.feedIndex(id)
+	if !ok {
+		return newsStorage.ErrNotFound
+	}
+	db.feeds[i].ETag = etag
+	db.feeds[i].LastModified = lastModified
 	return nil
 }
+
+// DeleteFeed deletes the news source. Its publications are kept without the
+// source identifier.
+func (db *DB) DeleteFeed(id int) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	i, ok := db.feedIndex(id)
+	if !ok {
+		return newsStorage.ErrNotFound
+	}
+	db.feeds = append(db.feeds[:i], db.feeds[i+1:]...)
+	for i := range db.posts {
+		if db.posts[i].SourceID == id {
+			db.posts[i].SourceID = 0
+		}
+	}
+	return nil
+}
+
+// feedIndex returns the index of the news source in db.feeds.
+func (db *DB) feedIndex(id int) (int, bool) {
+	i := sort.Search(len(db.feeds), func(i int) bool { return db.feeds[i].ID >= id })
+	return i, i < len(db.feeds) && db.feeds[i].ID == id
+}
+
+// clone returns a copy of the publication not sharing slices with it.
+func clone(p newsStorage.Post) newsStorage.Post {
+	p.Tags = append([]string(nil), p.Tags...)
+	p.Media = append([]newsStorage.Media(nil), p.Media...)
+	return p
+}
+
+// sortedTags normalizes the categories and sorts them as databases return them.
+func sortedTags(categories []string) []string {
+	tags := newsStorage.NormalizeTags(categories)
+	sort.Strings(tags)
+	return tags
+}
+
+// match reports whether the publication passes the filters of the query.
+func match(p newsStorage.Post, q newsStorage.Query) bool {
+	if q.From != 0 && p.PubTime < q.From {
+		return false
+	}
+	if q.To != 0 && p.PubTime > q.To {
+		return false
+	}
+	if len(q.SourceIDs) > 0 && !containsInt(q.SourceIDs, p.SourceID) {
+		return false
+	}
+	if tags := newsStorage.NormalizeTags(q.Tags); len(tags) > 0 {
+		found := false
+		for _, t := range tags {
+			if containsString(p.Tags, t) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// relevance returns the number of occurrences of the search words in the
+// publication, the title counting twice, or 0 if some word is missing.
+func relevance(p newsStorage.Post, words []string) int {
+	title := strings.ToLower(p.Title)
+	content := strings.ToLower(p.Content)
+	var rank int
+	for _, w := range words {
+		n := 2*strings.Count(title, w) + strings.Count(content, w)
+		if n == 0 {
+			return 0
+		}
+		rank += n
+	}
+	return rank
+}
+
+func containsInt(s []int, v int) bool {
+	for _, x := range s {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(s []string, v string) bool {
+	for _, x := range s {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
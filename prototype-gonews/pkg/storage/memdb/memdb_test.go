@@ -0,0 +1,13 @@
+package memdb
+
+import (
+	newsStorage "GoNews/news/pkg/storage"
+	"GoNews/news/pkg/storage/storagetest"
+	"testing"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.News(t, func(t *testing.T) newsStorage.NewsInterface {
+		return New()
+	})
+}
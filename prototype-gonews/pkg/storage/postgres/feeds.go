@@ -0,0 +1,177 @@
+package postgres
+
+import (
+	newsStorage "GoNews/news/pkg/storage"
+	"context"
+	"errors"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// Feeds returns all news sources.
+func (s *Storage) Feeds() ([]newsStorage.Feed, error) {
+	rows, err := s.db.Query(context.Background(), `
+		SELECT 
+			id,
+			url,
+			title,
+			category,
+			paused,
+			poll_interval,
+			full_text,
+			etag,
+			last_modified
+		FROM feeds
+		ORDER BY id;
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var feeds []newsStorage.Feed
+	for rows.Next() {
+		var f newsStorage.Feed
+		err = rows.Scan(
+			&f.ID,
+			&f.URL,
+			&f.Title,
+			&f.Category,
+			&f.Paused,
+			&f.Interval,
+			&f.FullText,
+			&f.ETag,
+			&f.LastModified,
+		)
+		if err != nil {
+			return nil, err
+		}
+		feeds = append(feeds, f)
+	}
+	return feeds, rows.Err()
+}
+
+// Feed returns a news source by its identifier.
+func (s *Storage) Feed(id int) (*newsStorage.Feed, error) {
+	var f newsStorage.Feed
+	err := s.db.QueryRow(context.Background(), `
+		SELECT 
+			id,
+			url,
+			title,
+			category,
+			paused,
+			poll_interval,
+			full_text,
+			etag,
+			last_modified
+		FROM feeds
+		WHERE id = $1
+	`, id).Scan(
+		&f.ID,
+		&f.URL,
+		&f.Title,
+		&f.Category,
+		&f.Paused,
+		&f.Interval,
+		&f.FullText,
+		&f.ETag,
+		&f.LastModified,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, newsStorage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// AddFeed creates a new news source and returns its identifier.
+func (s *Storage) AddFeed(f newsStorage.Feed) (int, error) {
+	var id int
+	err := s.db.QueryRow(context.Background(), `
+		INSERT INTO feeds(url, title, category, paused, poll_interval, full_text)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`,
+		f.URL,
+		f.Title,
+		f.Category,
+		f.Paused,
+		f.Interval,
+		f.FullText,
+	).Scan(&id)
+	return id, feedExists(err)
+}
+
+// UpdateFeed updates the news source. Its validators are reset if the
+// address changes.
+func (s *Storage) UpdateFeed(f newsStorage.Feed) error {
+	tag, err := s.db.Exec(context.Background(), `
+		UPDATE feeds
+		SET url = $2, title = $3, category = $4, paused = $5, poll_interval = $6, full_text = $7,
+			-- The validators of another address do not apply.
+			etag = CASE WHEN url = $2 THEN etag ELSE '' END,
+			last_modified = CASE WHEN url = $2 THEN last_modified ELSE '' END
+		WHERE id = $1`,
+		f.ID,
+		f.URL,
+		f.Title,
+		f.Category,
+		f.Paused,
+		f.Interval,
+		f.FullText,
+	)
+	if err != nil {
+		return feedExists(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return newsStorage.ErrNotFound
+	}
+	return nil
+}
+
+// SetFeedValidators stores the HTTP cache validators of the news source.
+func (s *Storage) SetFeedValidators(id int, etag, lastModified string) error {
+	tag, err := s.db.Exec(context.Background(), `
+		UPDATE feeds
+		SET etag = $2, last_modified = $3
+		WHERE id = $1`,
+		id,
+		etag,
+		lastModified,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return newsStorage.ErrNotFound
+	}
+	return nil
+}
+
+// DeleteFeed deletes the news source.
+func (s *Storage) DeleteFeed(id int) error {
+	tag, err := s.db.Exec(context.Background(), `
+		DELETE FROM feeds
+		WHERE id = $1`,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return newsStorage.ErrNotFound
+	}
+	return nil
+}
+
+// feedExists returns newsStorage.ErrFeedExists for a violation of the unique
+// feed URL and err otherwise.
+func feedExists(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return newsStorage.ErrFeedExists
+	}
+	return err
+}
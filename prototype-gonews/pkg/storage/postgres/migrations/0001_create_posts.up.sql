@@ -0,0 +1,9 @@
+-- The schema of the first release. Databases created from its schema.sql
+-- already have the table and get the version recorded.
+CREATE TABLE IF NOT EXISTS posts (
+    id SERIAL PRIMARY KEY,
+    title TEXT  NOT NULL,
+    content TEXT NOT NULL,
+    published_at BIGINT NOT NULL DEFAULT 0,
+    link TEXT NOT NULL UNIQUE
+);
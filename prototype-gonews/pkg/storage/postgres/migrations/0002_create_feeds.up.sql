@@ -0,0 +1,9 @@
+CREATE TABLE feeds (
+    id SERIAL PRIMARY KEY,
+    url TEXT NOT NULL UNIQUE,
+    title TEXT NOT NULL DEFAULT '',
+    category TEXT NOT NULL DEFAULT '',
+    paused BOOLEAN NOT NULL DEFAULT FALSE,
+    poll_interval INTEGER NOT NULL DEFAULT 0,
+    full_text BOOLEAN NOT NULL DEFAULT FALSE -- download full articles of truncated publications
+);
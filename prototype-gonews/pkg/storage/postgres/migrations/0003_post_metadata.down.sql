@@ -0,0 +1,22 @@
+DROP INDEX posts_published_at_idx;
+
+ALTER TABLE posts
+    DROP COLUMN search,
+    DROP COLUMN cluster_id,
+    DROP COLUMN simhash,
+    DROP COLUMN dedup_key,
+    DROP COLUMN guid,
+    DROP COLUMN author,
+    DROP COLUMN source_title,
+    DROP COLUMN source_id,
+    DROP COLUMN pubtime_estimated,
+    DROP COLUMN article,
+    DROP COLUMN content_html;
+
+-- The baseline schema has one publication per link. Of the publications
+-- sharing a link, such as the items of a feed with distinct GUIDs, the first
+-- one is kept.
+DELETE FROM posts
+WHERE id NOT IN (SELECT MIN(id) FROM posts GROUP BY link);
+
+ALTER TABLE posts ADD CONSTRAINT posts_link_key UNIQUE (link);
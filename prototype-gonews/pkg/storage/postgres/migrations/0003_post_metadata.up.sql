@@ -0,0 +1,31 @@
+ALTER TABLE posts
+    ADD COLUMN content_html TEXT NOT NULL DEFAULT '',
+    ADD COLUMN article TEXT NOT NULL DEFAULT '', -- full text extracted from the publication page
+    ADD COLUMN pubtime_estimated BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN source_id INTEGER REFERENCES feeds(id) ON DELETE SET NULL,
+    ADD COLUMN source_title TEXT NOT NULL DEFAULT '',
+    ADD COLUMN author TEXT NOT NULL DEFAULT '',
+    ADD COLUMN guid TEXT NOT NULL DEFAULT '',
+    ADD COLUMN dedup_key TEXT, -- GUID or normalized link
+    ADD COLUMN simhash BIGINT NOT NULL DEFAULT 0, -- fingerprint of the title and content
+    ADD COLUMN cluster_id INTEGER NOT NULL DEFAULT 0; -- first publication of the story
+
+-- The stored publications have no GUID, and their links are unique. Every
+-- one of them is a story of its own.
+UPDATE posts SET dedup_key = link, cluster_id = id;
+
+ALTER TABLE posts ALTER COLUMN dedup_key SET NOT NULL;
+ALTER TABLE posts DROP CONSTRAINT posts_link_key;
+ALTER TABLE posts ADD CONSTRAINT posts_dedup_key_key UNIQUE (dedup_key);
+
+ALTER TABLE posts ADD COLUMN search TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', title), 'A') ||
+    setweight(to_tsvector('russian', title), 'A') ||
+    setweight(to_tsvector('english', content), 'B') ||
+    setweight(to_tsvector('russian', content), 'B')
+) STORED;
+
+CREATE INDEX posts_search_idx ON posts USING GIN (search);
+
+CREATE INDEX posts_cluster_id_idx ON posts(cluster_id);
+CREATE INDEX posts_published_at_idx ON posts(published_at);
@@ -0,0 +1,3 @@
+ALTER TABLE feeds
+    DROP COLUMN last_modified,
+    DROP COLUMN etag;
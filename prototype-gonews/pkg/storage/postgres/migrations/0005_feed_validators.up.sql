@@ -0,0 +1,4 @@
+-- HTTP cache validators of the last response whose publications were saved.
+ALTER TABLE feeds
+    ADD COLUMN etag TEXT NOT NULL DEFAULT '',
+    ADD COLUMN last_modified TEXT NOT NULL DEFAULT '';
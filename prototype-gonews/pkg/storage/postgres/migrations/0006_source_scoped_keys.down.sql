@@ -0,0 +1,14 @@
+-- Before this migration a GUID was the key in all feeds. Only one of the
+-- publications sharing a GUID, or a GUID equal to the key of another
+-- publication, can get it back; the others keep the source-scoped key.
+UPDATE posts
+SET dedup_key = btrim(guid, E' \t\r\n')
+WHERE id IN (
+    SELECT MIN(id)
+    FROM posts
+    WHERE btrim(guid, E' \t\r\n') <> ''
+    GROUP BY btrim(guid, E' \t\r\n')
+)
+AND NOT EXISTS (
+    SELECT 1 FROM posts AS other WHERE other.dedup_key = btrim(posts.guid, E' \t\r\n')
+);
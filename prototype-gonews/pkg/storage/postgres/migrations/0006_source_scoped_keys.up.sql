@@ -0,0 +1,5 @@
+-- A GUID is only unique within its feed, so the key of a publication with a
+-- GUID includes the source feed.
+UPDATE posts
+SET dedup_key = 'guid:' || COALESCE(source_id, 0) || ':' || btrim(guid, E' \t\r\n')
+WHERE btrim(guid, E' \t\r\n') <> '';
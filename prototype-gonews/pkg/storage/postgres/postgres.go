@@ -1,14 +1,42 @@
 package postgres
 
 import (
+	"GoNews/news/pkg/dedup"
 	newsStorage "GoNews/news/pkg/storage"
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
-const pageSize int = 15
+// tagsColumn selects the tags of a publication as a sorted array.
+const tagsColumn = `ARRAY(
+				SELECT tags.name
+				FROM post_tags
+				JOIN tags ON tags.id = post_tags.tag_id
+				WHERE post_tags.post_id = posts.id
+				ORDER BY tags.name
+			)`
+
+// tsQuery parses the search query given as the parameter with English and
+// Russian stemming. Quotes, "or" and "-" work as in web search engines.
+const tsQuery = `(websearch_to_tsquery('english', $%[1]d) || websearch_to_tsquery('russian', $%[1]d))`
+
+// snippetColumn selects the fragments of the content matching the search query
+// given as the parameter, as HTML with the matches wrapped in <mark>.
+const snippetColumn = `CASE
+				WHEN to_tsvector('russian', content) @@ websearch_to_tsquery('russian', $%[1]d)
+				THEN ts_headline('russian', ` + escapedContent + `, websearch_to_tsquery('russian', $%[1]d), '` + headlineOptions + `')
+				ELSE ts_headline('english', ` + escapedContent + `, websearch_to_tsquery('english', $%[1]d), '` + headlineOptions + `')
+			END`
+
+// escapedContent is the plain text content escaped for HTML.
+const escapedContent = `replace(replace(replace(content, '&', '&amp;'), '<', '&lt;'), '>', '&gt;')`
+
+const headlineOptions = `StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=" … "`
 
 // Data storage.
 type Storage struct {
@@ -27,6 +55,12 @@ func New(constr string) (*Storage, error) {
 	return &s, nil
 }
 
+// Close closes the connection pool.
+func (s *Storage) Close() error {
+	s.db.Close()
+	return nil
+}
+
 // PostDetail returns detailed information about a publication by its identifier.
 func (s *Storage) PostDetail(id int) (*newsStorage.Post, error) {
 	var post newsStorage.Post
@@ -36,78 +70,177 @@ func (s *Storage) PostDetail(id int) (*newsStorage.Post, error) {
 			id,
 			title,
 			content,
+			content_html,
+			article,
 			published_at,
-			link
+			pubtime_estimated,
+			link,
+			COALESCE(source_id, 0),
+			source_title,
+			author,
+			guid,
+			cluster_id,
+			`+tagsColumn+`
 		FROM posts
 		WHERE id = $1
 	`, id).Scan(
 		&post.ID,
 		&post.Title,
 		&post.Content,
+		&post.ContentHTML,
+		&post.Article,
 		&post.PubTime,
+		&post.PubTimeEstimated,
 		&post.Link,
+		&post.SourceID,
+		&post.SourceTitle,
+		&post.Author,
+		&post.GUID,
+		&post.ClusterID,
+		&post.Tags,
 	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, newsStorage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	post.Media, err = s.media(post.ID)
 	if err != nil {
 		return nil, err
 	}
 	return &post, nil
 }
 
-// Posts returns the publications from the database.
-func (s *Storage) Posts(page int, searchQuery string) ([]newsStorage.Post, newsStorage.Pagination, error) {
-	if page <= 0 {
-		page = 1
+// media returns the media files attached to the publication.
+func (s *Storage) media(postID int) ([]newsStorage.Media, error) {
+	rows, err := s.db.Query(context.Background(), `
+		SELECT 
+			url,
+			type,
+			medium,
+			width,
+			height,
+			length
+		FROM post_media
+		WHERE post_id = $1
+		ORDER BY id;
+	`, postID)
+	if err != nil {
+		return nil, err
 	}
-	var totalPages int
-	var totalPosts int
-	var rows pgx.Rows
-	var err error
-	if searchQuery != "" {
-		err = s.db.QueryRow(context.Background(), `
-            SELECT COUNT(*)
-            FROM posts
-            WHERE title ILIKE $1;
-        `, "%"+searchQuery+"%").Scan(&totalPosts)
-		if err != nil {
-			return nil, newsStorage.Pagination{}, err
-		}
-		totalPages = (totalPosts + pageSize - 1) / pageSize
-		offset := (page - 1) * pageSize
-		rows, err = s.db.Query(context.Background(), `
-            SELECT 
-                id,
-                title,
-                content,
-                published_at,
-                link
-            FROM posts
-            WHERE title ILIKE $1
-            ORDER BY id DESC
-            LIMIT $2 OFFSET $3;
-        `, "%"+searchQuery+"%", pageSize, offset)
-	} else {
-		err = s.db.QueryRow(context.Background(), `
-            SELECT COUNT(*)
-            FROM posts;
-        `).Scan(&totalPosts)
+	defer rows.Close()
+	var media []newsStorage.Media
+	for rows.Next() {
+		var m newsStorage.Media
+		err = rows.Scan(
+			&m.URL,
+			&m.Type,
+			&m.Medium,
+			&m.Width,
+			&m.Height,
+			&m.Length,
+		)
 		if err != nil {
-			return nil, newsStorage.Pagination{}, err
+			return nil, err
 		}
-		totalPages = (totalPosts + pageSize - 1) / pageSize
-		offset := (page - 1) * pageSize
-		rows, err = s.db.Query(context.Background(), `
-            SELECT 
-                id,
-                title,
-                content,
-                published_at,
-                link
-            FROM posts
-            ORDER BY id DESC
-            LIMIT $1 OFFSET $2;
-        `, pageSize, offset)
+		media = append(media, m)
 	}
+	return media, rows.Err()
+}
 
+// Posts returns a page of publications selected by the query. Publications
+// found by a full-text search over the title and content have a highlighted
+// snippet. Only the first matching publication of every story is returned.
+// Pages in the publication time order are also linked by cursors.
+func (s *Storage) Posts(q newsStorage.Query) ([]newsStorage.Post, newsStorage.Pagination, error) {
+	page := q.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := q.Limit()
+	var conditions []string
+	var args []interface{}
+	var rank string
+	snippet := "''"
+	if q.Search != "" {
+		args = append(args, q.Search)
+		query := fmt.Sprintf(tsQuery, len(args))
+		conditions = append(conditions, "search @@ "+query)
+		rank = "ts_rank(search, " + query + ")"
+		snippet = fmt.Sprintf(snippetColumn, len(args))
+	}
+	if q.From != 0 {
+		args = append(args, q.From)
+		conditions = append(conditions, fmt.Sprintf("published_at >= $%d", len(args)))
+	}
+	if q.To != 0 {
+		args = append(args, q.To)
+		conditions = append(conditions, fmt.Sprintf("published_at <= $%d", len(args)))
+	}
+	if len(q.SourceIDs) > 0 {
+		args = append(args, q.SourceIDs)
+		conditions = append(conditions, fmt.Sprintf("source_id = ANY($%d)", len(args)))
+	}
+	if tags := newsStorage.NormalizeTags(q.Tags); len(tags) > 0 {
+		args = append(args, tags)
+		conditions = append(conditions, fmt.Sprintf(`EXISTS (
+			SELECT 1
+			FROM post_tags
+			JOIN tags ON tags.id = post_tags.tag_id
+			WHERE post_tags.post_id = posts.id AND tags.name = ANY($%d)
+		)`, len(args)))
+	}
+	order := orderBy(q, rank)
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var totalPosts int
+	err := s.db.QueryRow(context.Background(), `
+		SELECT COUNT(DISTINCT cluster_id)
+		FROM posts
+		`+where, args...).Scan(&totalPosts)
+	if err != nil {
+		return nil, newsStorage.Pagination{}, err
+	}
+	totalPages := (totalPosts + pageSize - 1) / pageSize
+	offset := (page - 1) * pageSize
+	var after string
+	if q.Cursor != nil {
+		page, offset = 0, 0
+		after, args = keyset(*q.Cursor, q.Asc, args)
+	}
+	// One more publication tells whether the next page exists.
+	args = append(args, pageSize+1, offset)
+	rows, err := s.db.Query(context.Background(), `
+		SELECT 
+			id,
+			title,
+			content,
+			content_html,
+			published_at,
+			pubtime_estimated,
+			link,
+			COALESCE(source_id, 0),
+			source_title,
+			author,
+			guid,
+			cluster_id,
+			`+tagsColumn+`,
+			`+snippet+`
+		FROM posts
+		WHERE id IN (
+			SELECT MIN(id)
+			FROM posts
+			`+where+`
+			GROUP BY cluster_id
+		)
+		`+after+`
+		ORDER BY `+order+fmt.Sprintf(`
+		LIMIT $%d OFFSET $%d;
+	`, len(args)-1, len(args)), args...)
 	if err != nil {
 		return nil, newsStorage.Pagination{}, err
 	}
@@ -120,38 +253,330 @@ func (s *Storage) Posts(page int, searchQuery string) ([]newsStorage.Post, newsS
 			&p.ID,
 			&p.Title,
 			&p.Content,
+			&p.ContentHTML,
 			&p.PubTime,
+			&p.PubTimeEstimated,
 			&p.Link,
+			&p.SourceID,
+			&p.SourceTitle,
+			&p.Author,
+			&p.GUID,
+			&p.ClusterID,
+			&p.Tags,
+			&p.Snippet,
 		)
 		if err != nil {
 			return nil, newsStorage.Pagination{}, err
 		}
 		posts = append(posts, p)
 	}
+	if err = rows.Err(); err != nil {
+		return nil, newsStorage.Pagination{}, err
+	}
+	more := len(posts) > pageSize
+	if more {
+		posts = posts[:pageSize]
+	}
+	if q.Cursor != nil && q.Cursor.Before {
+		for i, j := 0, len(posts)-1; i < j; i, j = i+1, j-1 {
+			posts[i], posts[j] = posts[j], posts[i]
+		}
+	}
 
 	pagination := newsStorage.Pagination{
 		TotalPages:  totalPages,
 		CurrentPage: page,
 		PageSize:    pageSize,
+		TotalItems:  totalPosts,
 	}
+	pagination.NextCursor, pagination.PrevCursor = newsStorage.Cursors(posts, q, more)
 
 	return posts, pagination, nil
 }
 
-// AddPosts creates a new publications in the database.
-func (s *Storage) AddPosts(posts []newsStorage.Post) error {
-	for _, post := range posts {
-		_, err := s.db.Exec(context.Background(), `
-		INSERT INTO posts(title, content, published_at, link)
-		VALUES ($1, $2, $3, $4)`,
+// Tags returns the tags with the number of publications marked with them,
+// the most used first.
+func (s *Storage) Tags() ([]newsStorage.Tag, error) {
+	rows, err := s.db.Query(context.Background(), `
+		SELECT 
+			tags.name,
+			COUNT(*)
+		FROM tags
+		JOIN post_tags ON post_tags.tag_id = tags.id
+		GROUP BY tags.name
+		ORDER BY COUNT(*) DESC, tags.name;
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tags []newsStorage.Tag
+	for rows.Next() {
+		var t newsStorage.Tag
+		err = rows.Scan(
+			&t.Name,
+			&t.Count,
+		)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+// orderBy returns the ORDER BY list of the query. The id breaks ties, so the
+// order is stable between pages. A page before a cursor is selected in the
+// reverse order.
+func orderBy(q newsStorage.Query, rank string) string {
+	asc := q.Asc
+	if q.Cursor != nil && q.Cursor.Before {
+		asc = !asc
+	}
+	dir := " DESC"
+	if asc {
+		dir = " ASC"
+	}
+	switch sort := q.SortOrder(); {
+	case sort == newsStorage.SortPubTime:
+		return "published_at" + dir + ", id" + dir
+	case sort == newsStorage.SortRelevance && rank != "":
+		return rank + dir + ", id" + dir
+	}
+	return "id" + dir
+}
+
+// keyset returns the condition selecting the publications past the cursor.
+func keyset(c newsStorage.Cursor, asc bool, args []interface{}) (string, []interface{}) {
+	op := "<"
+	if asc != c.Before {
+		op = ">"
+	}
+	if c.Sort == newsStorage.SortID {
+		args = append(args, c.ID)
+		return fmt.Sprintf("AND id %s $%d", op, len(args)), args
+	}
+	args = append(args, c.PubTime, c.ID)
+	return fmt.Sprintf("AND (published_at, id) %s ($%d, $%d)", op, len(args)-1, len(args)), args
+}
+
+// ClusterPosts returns the publications of the stories without their content
+// and media, in the order of addition.
+func (s *Storage) ClusterPosts(clusters []int) ([]newsStorage.Post, error) {
+	rows, err := s.db.Query(context.Background(), `
+		SELECT 
+			id,
+			title,
+			published_at,
+			pubtime_estimated,
+			link,
+			COALESCE(source_id, 0),
+			source_title,
+			author,
+			guid,
+			cluster_id
+		FROM posts
+		WHERE cluster_id = ANY($1)
+		ORDER BY id;
+	`, clusters)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var posts []newsStorage.Post
+	for rows.Next() {
+		var p newsStorage.Post
+		err = rows.Scan(
+			&p.ID,
+			&p.Title,
+			&p.PubTime,
+			&p.PubTimeEstimated,
+			&p.Link,
+			&p.SourceID,
+			&p.SourceTitle,
+			&p.Author,
+			&p.GUID,
+			&p.ClusterID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, p)
+	}
+	return posts, rows.Err()
+}
+
+// clusterIndex returns the index of the stored publications which the new
+// publications can be near-duplicates of. Publications inserted by the
+// transaction have no cluster yet and are left out.
+func clusterIndex(ctx context.Context, tx pgx.Tx, posts []dedup.Entry) (*dedup.Index, error) {
+	from, to := posts[0].PubTime, posts[0].PubTime
+	for _, p := range posts {
+		if p.PubTime < from {
+			from = p.PubTime
+		}
+		if p.PubTime > to {
+			to = p.PubTime
+		}
+	}
+	rows, err := tx.Query(ctx, `
+		SELECT 
+			id,
+			cluster_id,
+			simhash,
+			published_at
+		FROM posts
+		WHERE simhash <> 0 AND cluster_id <> 0 AND published_at BETWEEN $1 AND $2;
+	`, from-dedup.Window, to+dedup.Window)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var entries []dedup.Entry
+	for rows.Next() {
+		var e dedup.Entry
+		var fp int64
+		err = rows.Scan(
+			&e.ID,
+			&e.Cluster,
+			&fp,
+			&e.PubTime,
+		)
+		if err != nil {
+			return nil, err
+		}
+		e.Fingerprint = uint64(fp)
+		entries = append(entries, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return dedup.NewIndex(entries), nil
+}
+
+// AddPosts inserts new publications and updates the edited ones in a single
+// transaction. Publications are matched by newsStorage.Key. A publication
+// without an article keeps the stored one. New publications join the story
+// of their near-duplicates, edited ones stay in their story.
+func (s *Storage) AddPosts(posts []newsStorage.Post) (newsStorage.AddResult, error) {
+	var res newsStorage.AddResult
+	if len(posts) == 0 {
+		return res, nil
+	}
+	ctx := context.Background()
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return res, err
+	}
+	defer tx.Rollback(ctx)
+
+	fingerprints := make([]uint64, len(posts))
+	for i, post := range posts {
+		fingerprints[i] = dedup.Fingerprint(post.Title, post.Content)
+	}
+
+	batch := &pgx.Batch{}
+	for i, post := range posts {
+		batch.Queue(`
+		INSERT INTO posts(title, content, content_html, article, published_at, pubtime_estimated, link, source_id, source_title, author, guid, dedup_key, simhash)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, 0), $9, $10, $11, $12, $13)
+		ON CONFLICT (dedup_key) DO UPDATE
+		SET title = EXCLUDED.title, content = EXCLUDED.content, content_html = EXCLUDED.content_html,
+			article = COALESCE(NULLIF(EXCLUDED.article, ''), posts.article),
+			author = EXCLUDED.author, link = EXCLUDED.link, simhash = EXCLUDED.simhash
+		WHERE posts.title <> EXCLUDED.title OR posts.content_html <> EXCLUDED.content_html
+			OR (EXCLUDED.article <> '' AND posts.article <> EXCLUDED.article)
+		RETURNING id, xmax = 0`,
 			post.Title,
 			post.Content,
+			post.ContentHTML,
+			post.Article,
 			post.PubTime,
+			post.PubTimeEstimated,
 			post.Link,
+			post.SourceID,
+			post.SourceTitle,
+			post.Author,
+			post.GUID,
+			newsStorage.Key(post),
+			int64(fingerprints[i]),
 		)
+	}
+	br := tx.SendBatch(ctx, batch)
+	// Tags and media of the inserted and updated publications are replaced.
+	relBatch := &pgx.Batch{}
+	var added []dedup.Entry // inserted publications to cluster
+	for i, post := range posts {
+		// xmax is zero only for freshly inserted rows; no row means the
+		// stored publication is unchanged.
+		var id int
+		var inserted bool
+		err = br.QueryRow().Scan(&id, &inserted)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			res.Skipped++
+			continue
+		case err != nil:
+			br.Close()
+			return newsStorage.AddResult{}, err
+		case inserted:
+			res.Inserted++
+			added = append(added, dedup.Entry{ID: id, Fingerprint: fingerprints[i], PubTime: post.PubTime})
+		default:
+			res.Updated++
+		}
+		relBatch.Queue(`DELETE FROM post_tags WHERE post_id = $1`, id)
+		for _, tag := range newsStorage.NormalizeTags(post.Tags) {
+			relBatch.Queue(`
+			INSERT INTO tags(name)
+			VALUES ($1)
+			ON CONFLICT (name) DO NOTHING`,
+				tag,
+			)
+			relBatch.Queue(`
+			INSERT INTO post_tags(post_id, tag_id)
+			SELECT $1, id FROM tags WHERE name = $2
+			ON CONFLICT DO NOTHING`,
+				id,
+				tag,
+			)
+		}
+		relBatch.Queue(`DELETE FROM post_media WHERE post_id = $1`, id)
+		for _, m := range post.Media {
+			relBatch.Queue(`
+			INSERT INTO post_media(post_id, url, type, medium, width, height, length)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+				id,
+				m.URL,
+				m.Type,
+				m.Medium,
+				m.Width,
+				m.Height,
+				m.Length,
+			)
+		}
+	}
+	err = br.Close()
+	if err != nil {
+		return newsStorage.AddResult{}, err
+	}
+	// The stored publications are loaded only if there are new ones to
+	// cluster.
+	if len(added) > 0 {
+		index, err := clusterIndex(ctx, tx, added)
 		if err != nil {
-			return err
+			return newsStorage.AddResult{}, err
+		}
+		for _, e := range added {
+			relBatch.Queue(`UPDATE posts SET cluster_id = $2 WHERE id = $1`,
+				e.ID,
+				index.Add(e.ID, e.Fingerprint, e.PubTime),
+			)
 		}
 	}
-	return nil
+	err = tx.SendBatch(ctx, relBatch).Close()
+	if err != nil {
+		return newsStorage.AddResult{}, err
+	}
+	return res, tx.Commit(ctx)
 }
@@ -0,0 +1,59 @@
+package newsStorage
+
+import (
+	"html"
+	"strings"
+)
+
+// Words of a search snippet before the first match and in total.
+const (
+	snippetBefore = 10
+	snippetWords  = 35
+)
+
+// SearchWords splits a search query into lowercase words for the storages
+// without a full-text search engine.
+func SearchWords(query string) []string {
+	return strings.Fields(strings.ToLower(query))
+}
+
+// Snippet returns the words of the content around the first match of the
+// search words as HTML with the matching words wrapped in <mark>.
+func Snippet(content string, words []string) string {
+	fields := strings.Fields(content)
+	matches := func(field string) bool {
+		field = strings.ToLower(field)
+		for _, w := range words {
+			if strings.Contains(field, w) {
+				return true
+			}
+		}
+		return false
+	}
+	start := 0
+	for i, f := range fields {
+		if matches(f) {
+			start = i - snippetBefore
+			break
+		}
+	}
+	if start < 0 {
+		start = 0
+	}
+	end := start + snippetWords
+	if end > len(fields) {
+		end = len(fields)
+	}
+	var b strings.Builder
+	for i := start; i < end; i++ {
+		if i > start {
+			b.WriteByte(' ')
+		}
+		if matches(fields[i]) {
+			b.WriteString("<mark>" + html.EscapeString(fields[i]) + "</mark>")
+		} else {
+			b.WriteString(html.EscapeString(fields[i]))
+		}
+	}
+	return b.String()
+}
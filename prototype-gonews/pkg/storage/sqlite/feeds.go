@@ -0,0 +1,182 @@
+package sqlite
+
+import (
+	newsStorage "GoNews/news/pkg/storage"
+	"database/sql"
+	"errors"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Feeds returns all news sources.
+func (s *Storage) Feeds() ([]newsStorage.Feed, error) {
+	rows, err := s.db.Query(`
+		SELECT 
+			id,
+			url,
+			title,
+			category,
+			paused,
+			poll_interval,
+			full_text,
+			etag,
+			last_modified
+		FROM feeds
+		ORDER BY id;
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var feeds []newsStorage.Feed
+	for rows.Next() {
+		var f newsStorage.Feed
+		err = rows.Scan(
+			&f.ID,
+			&f.URL,
+			&f.Title,
+			&f.Category,
+			&f.Paused,
+			&f.Interval,
+			&f.FullText,
+			&f.ETag,
+			&f.LastModified,
+		)
+		if err != nil {
+			return nil, err
+		}
+		feeds = append(feeds, f)
+	}
+	return feeds, rows.Err()
+}
+
+// Feed returns a news source by its identifier.
+func (s *Storage) Feed(id int) (*newsStorage.Feed, error) {
+	var f newsStorage.Feed
+	err := s.db.QueryRow(`
+		SELECT 
+			id,
+			url,
+			title,
+			category,
+			paused,
+			poll_interval,
+			full_text,
+			etag,
+			last_modified
+		FROM feeds
+		WHERE id = ?1
+	`, id).Scan(
+		&f.ID,
+		&f.URL,
+		&f.Title,
+		&f.Category,
+		&f.Paused,
+		&f.Interval,
+		&f.FullText,
+		&f.ETag,
+		&f.LastModified,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, newsStorage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// AddFeed creates a new news source and returns its identifier.
+func (s *Storage) AddFeed(f newsStorage.Feed) (int, error) {
+	r, err := s.db.Exec(`
+		INSERT INTO feeds(url, title, category, paused, poll_interval, full_text)
+		VALUES (?1, ?2, ?3, ?4, ?5, ?6)`,
+		f.URL,
+		f.Title,
+		f.Category,
+		f.Paused,
+		f.Interval,
+		f.FullText,
+	)
+	if err != nil {
+		return 0, feedExists(err)
+	}
+	id, err := r.LastInsertId()
+	return int(id), err
+}
+
+// UpdateFeed updates the news source. Its validators are reset if the
+// address changes.
+func (s *Storage) UpdateFeed(f newsStorage.Feed) error {
+	r, err := s.db.Exec(`
+		UPDATE feeds
+		SET url = ?2, title = ?3, category = ?4, paused = ?5, poll_interval = ?6, full_text = ?7,
+			-- The validators of another address do not apply.
+			etag = CASE WHEN url = ?2 THEN etag ELSE '' END,
+			last_modified = CASE WHEN url = ?2 THEN last_modified ELSE '' END
+		WHERE id = ?1`,
+		f.ID,
+		f.URL,
+		f.Title,
+		f.Category,
+		f.Paused,
+		f.Interval,
+		f.FullText,
+	)
+	if err != nil {
+		return feedExists(err)
+	}
+	return notFound(r)
+}
+
+// SetFeedValidators stores the HTTP cache validators of the news source.
+func (s *Storage) SetFeedValidators(id int, etag, lastModified string) error {
+	r, err := s.db.Exec(`
+		UPDATE feeds
+		SET etag = ?2, last_modified = ?3
+		WHERE id = ?1`,
+		id,
+		etag,
+		lastModified,
+	)
+	if err != nil {
+		return err
+	}
+	return notFound(r)
+}
+
+// DeleteFeed deletes the news source.
+func (s *Storage) DeleteFeed(id int) error {
+	r, err := s.db.Exec(`
+		DELETE FROM feeds
+		WHERE id = ?1`,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+	return notFound(r)
+}
+
+// notFound returns newsStorage.ErrNotFound if the statement affected no rows.
+func notFound(r sql.Result) error {
+	n, err := r.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return newsStorage.ErrNotFound
+	}
+	return nil
+}
+
+// feedExists returns newsStorage.ErrFeedExists for a violation of the unique
+// feed URL and err otherwise.
+func feedExists(err error) error {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+		return newsStorage.ErrFeedExists
+	}
+	return err
+}
@@ -0,0 +1,44 @@
+package sqlite
+
+import (
+	"GoNews/news/pkg/migrate"
+	"database/sql"
+	"embed"
+	"io/fs"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Migrator returns the schema migrator of the database file.
+func Migrator(path string) (*migrate.Migrator, error) {
+	db, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	m, err := migrator(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+// migrator returns the migrator of the database schema.
+func migrator(db *sql.DB) (*migrate.Migrator, error) {
+	files, err := fs.Sub(migrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(db, files)
+}
+
+// migrateUp applies the pending migrations.
+func migrateUp(db *sql.DB) error {
+	m, err := migrator(db)
+	if err != nil {
+		return err
+	}
+	_, err = m.Up(0)
+	return err
+}
@@ -0,0 +1,56 @@
+CREATE TABLE IF NOT EXISTS feeds (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    url TEXT NOT NULL UNIQUE,
+    title TEXT NOT NULL DEFAULT '',
+    category TEXT NOT NULL DEFAULT '',
+    paused BOOLEAN NOT NULL DEFAULT FALSE,
+    poll_interval INTEGER NOT NULL DEFAULT 0,
+    full_text BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE TABLE IF NOT EXISTS posts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    content TEXT NOT NULL,
+    content_html TEXT NOT NULL DEFAULT '',
+    article TEXT NOT NULL DEFAULT '',
+    published_at INTEGER NOT NULL DEFAULT 0,
+    pubtime_estimated BOOLEAN NOT NULL DEFAULT FALSE,
+    link TEXT NOT NULL,
+    source_id INTEGER REFERENCES feeds(id) ON DELETE SET NULL,
+    source_title TEXT NOT NULL DEFAULT '',
+    author TEXT NOT NULL DEFAULT '',
+    guid TEXT NOT NULL DEFAULT '',
+    dedup_key TEXT NOT NULL UNIQUE,
+    simhash INTEGER NOT NULL DEFAULT 0,
+    cluster_id INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS posts_cluster_id_idx ON posts(cluster_id);
+CREATE INDEX IF NOT EXISTS posts_published_at_idx ON posts(published_at);
+
+CREATE TABLE IF NOT EXISTS post_media (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    type TEXT NOT NULL DEFAULT '',
+    medium TEXT NOT NULL DEFAULT '',
+    width INTEGER NOT NULL DEFAULT 0,
+    height INTEGER NOT NULL DEFAULT 0,
+    length INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS post_media_post_id_idx ON post_media(post_id);
+
+CREATE TABLE IF NOT EXISTS tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS post_tags (
+    post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (post_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS post_tags_tag_id_idx ON post_tags(tag_id);
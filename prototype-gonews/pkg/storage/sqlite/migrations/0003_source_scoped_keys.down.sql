@@ -0,0 +1,14 @@
+-- Before this migration a GUID was the key in all feeds. Only one of the
+-- publications sharing a GUID, or a GUID equal to the key of another
+-- publication, can get it back; the others keep the source-scoped key.
+UPDATE posts
+SET dedup_key = trim(guid, ' ' || char(9, 10, 13))
+WHERE id IN (
+    SELECT MIN(id)
+    FROM posts
+    WHERE trim(guid, ' ' || char(9, 10, 13)) <> ''
+    GROUP BY trim(guid, ' ' || char(9, 10, 13))
+)
+AND NOT EXISTS (
+    SELECT 1 FROM posts AS other WHERE other.dedup_key = trim(posts.guid, ' ' || char(9, 10, 13))
+);
@@ -0,0 +1,660 @@
+// Package sqlite stores publications and news sources in an SQLite database
+// file. Pending schema migrations are applied on opening.
+package sqlite
+
+import (
+	"GoNews/news/pkg/dedup"
+	newsStorage "GoNews/news/pkg/storage"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+
+	"modernc.org/sqlite"
+)
+
+// unicode_lower folds the case of all letters, as the built-in lower() only
+// folds ASCII ones.
+func init() {
+	sqlite.MustRegisterDeterministicScalarFunction("unicode_lower", 1, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		s, ok := args[0].(string)
+		if !ok {
+			return args[0], nil
+		}
+		return strings.ToLower(s), nil
+	})
+}
+
+// tagsColumn selects the tags of a publication sorted and joined by tagsSep.
+const tagsColumn = `COALESCE((
+				SELECT group_concat(name, char(31))
+				FROM (
+					SELECT tags.name
+					FROM post_tags
+					JOIN tags ON tags.id = post_tags.tag_id
+					WHERE post_tags.post_id = posts.id
+					ORDER BY tags.name
+				)
+			), '')`
+
+const tagsSep = "\x1f"
+
+// Data storage.
+type Storage struct {
+	db *sql.DB
+}
+
+// Constructor creates a new Storage object for the database file, or for an
+// in-memory database if the path is ":memory:".
+func New(path string) (*Storage, error) {
+	db, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	err = migrateUp(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	s := Storage{
+		db: db,
+	}
+	return &s, nil
+}
+
+// open opens the database file.
+func open(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer, and every connection to ":memory:" opens
+	// a separate database.
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// Close closes the database.
+func (s *Storage) Close() error {
+	return s.db.Close()
+}
+
+// PostDetail returns detailed information about a publication by its identifier.
+func (s *Storage) PostDetail(id int) (*newsStorage.Post, error) {
+	var post newsStorage.Post
+	var tags string
+	err := s.db.QueryRow(`
+		SELECT 
+			id,
+			title,
+			content,
+			content_html,
+			article,
+			published_at,
+			pubtime_estimated,
+			link,
+			COALESCE(source_id, 0),
+			source_title,
+			author,
+			guid,
+			cluster_id,
+			`+tagsColumn+`
+		FROM posts
+		WHERE id = ?1
+	`, id).Scan(
+		&post.ID,
+		&post.Title,
+		&post.Content,
+		&post.ContentHTML,
+		&post.Article,
+		&post.PubTime,
+		&post.PubTimeEstimated,
+		&post.Link,
+		&post.SourceID,
+		&post.SourceTitle,
+		&post.Author,
+		&post.GUID,
+		&post.ClusterID,
+		&tags,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, newsStorage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	post.Tags = splitTags(tags)
+	post.Media, err = s.media(post.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+// media returns the media files attached to the publication.
+func (s *Storage) media(postID int) ([]newsStorage.Media, error) {
+	rows, err := s.db.Query(`
+		SELECT 
+			url,
+			type,
+			medium,
+			width,
+			height,
+			length
+		FROM post_media
+		WHERE post_id = ?1
+		ORDER BY id;
+	`, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var media []newsStorage.Media
+	for rows.Next() {
+		var m newsStorage.Media
+		err = rows.Scan(
+			&m.URL,
+			&m.Type,
+			&m.Medium,
+			&m.Width,
+			&m.Height,
+			&m.Length,
+		)
+		if err != nil {
+			return nil, err
+		}
+		media = append(media, m)
+	}
+	return media, rows.Err()
+}
+
+// Posts returns a page of publications selected by the query. The search
+// matches publications containing every word of the query in the title or
+// content, case-insensitively, and ranks them by the number of occurrences.
+// Found publications have a highlighted snippet. Only the first matching
+// publication of every story is returned.
+func (s *Storage) Posts(q newsStorage.Query) ([]newsStorage.Post, newsStorage.Pagination, error) {
+	page := q.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := q.Limit()
+	var conditions []string
+	var args []interface{}
+	var ranks []string
+	words := newsStorage.SearchWords(q.Search)
+	for _, w := range words {
+		args = append(args, w)
+		conditions = append(conditions, fmt.Sprintf(
+			"(instr(unicode_lower(title), ?%[1]d) > 0 OR instr(unicode_lower(content), ?%[1]d) > 0)", len(args)))
+		ranks = append(ranks, fmt.Sprintf(
+			"(2 * (length(title) - length(replace(unicode_lower(title), ?%[1]d, ''))) + length(content) - length(replace(unicode_lower(content), ?%[1]d, ''))) / length(?%[1]d)",
+			len(args)))
+	}
+	if q.From != 0 {
+		args = append(args, q.From)
+		conditions = append(conditions, fmt.Sprintf("published_at >= ?%d", len(args)))
+	}
+	if q.To != 0 {
+		args = append(args, q.To)
+		conditions = append(conditions, fmt.Sprintf("published_at <= ?%d", len(args)))
+	}
+	if len(q.SourceIDs) > 0 {
+		var ids []string
+		for _, id := range q.SourceIDs {
+			args = append(args, id)
+			ids = append(ids, fmt.Sprintf("?%d", len(args)))
+		}
+		conditions = append(conditions, "source_id IN ("+strings.Join(ids, ", ")+")")
+	}
+	if tags := newsStorage.NormalizeTags(q.Tags); len(tags) > 0 {
+		var names []string
+		for _, t := range tags {
+			args = append(args, t)
+			names = append(names, fmt.Sprintf("?%d", len(args)))
+		}
+		conditions = append(conditions, `EXISTS (
+			SELECT 1
+			FROM post_tags
+			JOIN tags ON tags.id = post_tags.tag_id
+			WHERE post_tags.post_id = posts.id AND tags.name IN (`+strings.Join(names, ", ")+`)
+		)`)
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	order := orderBy(q, strings.Join(ranks, " + "))
+
+	var totalPosts int
+	err := s.db.QueryRow(`
+		SELECT COUNT(DISTINCT cluster_id)
+		FROM posts
+		`+where, args...).Scan(&totalPosts)
+	if err != nil {
+		return nil, newsStorage.Pagination{}, err
+	}
+	totalPages := (totalPosts + pageSize - 1) / pageSize
+	offset := (page - 1) * pageSize
+	var after string
+	if q.Cursor != nil {
+		page, offset = 0, 0
+		after, args = keyset(*q.Cursor, q.Asc, args)
+	}
+	// One more publication tells whether the next page exists.
+	args = append(args, pageSize+1, offset)
+	rows, err := s.db.Query(`
+		SELECT 
+			id,
+			title,
+			content,
+			content_html,
+			published_at,
+			pubtime_estimated,
+			link,
+			COALESCE(source_id, 0),
+			source_title,
+			author,
+			guid,
+			cluster_id,
+			`+tagsColumn+`
+		FROM posts
+		WHERE id IN (
+			SELECT MIN(id)
+			FROM posts
+			`+where+`
+			GROUP BY cluster_id
+		)
+		`+after+`
+		ORDER BY `+order+fmt.Sprintf(`
+		LIMIT ?%d OFFSET ?%d;
+	`, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, newsStorage.Pagination{}, err
+	}
+	defer rows.Close()
+
+	var posts []newsStorage.Post
+	for rows.Next() {
+		var p newsStorage.Post
+		var tags string
+		err := rows.Scan(
+			&p.ID,
+			&p.Title,
+			&p.Content,
+			&p.ContentHTML,
+			&p.PubTime,
+			&p.PubTimeEstimated,
+			&p.Link,
+			&p.SourceID,
+			&p.SourceTitle,
+			&p.Author,
+			&p.GUID,
+			&p.ClusterID,
+			&tags,
+		)
+		if err != nil {
+			return nil, newsStorage.Pagination{}, err
+		}
+		p.Tags = splitTags(tags)
+		if len(words) > 0 {
+			p.Snippet = newsStorage.Snippet(p.Content, words)
+		}
+		posts = append(posts, p)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, newsStorage.Pagination{}, err
+	}
+	more := len(posts) > pageSize
+	if more {
+		posts = posts[:pageSize]
+	}
+	if q.Cursor != nil && q.Cursor.Before {
+		for i, j := 0, len(posts)-1; i < j; i, j = i+1, j-1 {
+			posts[i], posts[j] = posts[j], posts[i]
+		}
+	}
+
+	pagination := newsStorage.Pagination{
+		TotalPages:  totalPages,
+		CurrentPage: page,
+		PageSize:    pageSize,
+		TotalItems:  totalPosts,
+	}
+	pagination.NextCursor, pagination.PrevCursor = newsStorage.Cursors(posts, q, more)
+
+	return posts, pagination, nil
+}
+
+// orderBy returns the ORDER BY list of the query. The id breaks ties, so the
+// order is stable between pages. A page before a cursor is selected in the
+// reverse order.
+func orderBy(q newsStorage.Query, rank string) string {
+	asc := q.Asc
+	if q.Cursor != nil && q.Cursor.Before {
+		asc = !asc
+	}
+	dir := " DESC"
+	if asc {
+		dir = " ASC"
+	}
+	switch sort := q.SortOrder(); {
+	case sort == newsStorage.SortPubTime:
+		return "published_at" + dir + ", id" + dir
+	case sort == newsStorage.SortRelevance && rank != "":
+		return "(" + rank + ")" + dir + ", id" + dir
+	}
+	return "id" + dir
+}
+
+// keyset returns the condition selecting the publications past the cursor.
+func keyset(c newsStorage.Cursor, asc bool, args []interface{}) (string, []interface{}) {
+	op := "<"
+	if asc != c.Before {
+		op = ">"
+	}
+	if c.Sort == newsStorage.SortID {
+		args = append(args, c.ID)
+		return fmt.Sprintf("AND id %s ?%d", op, len(args)), args
+	}
+	args = append(args, c.PubTime, c.ID)
+	return fmt.Sprintf("AND (published_at, id) %s (?%d, ?%d)", op, len(args)-1, len(args)), args
+}
+
+// splitTags splits the tags selected by tagsColumn.
+func splitTags(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, tagsSep)
+}
+
+// Tags returns the tags with the number of publications marked with them,
+// the most used first.
+func (s *Storage) Tags() ([]newsStorage.Tag, error) {
+	rows, err := s.db.Query(`
+		SELECT 
+			tags.name,
+			COUNT(*)
+		FROM tags
+		JOIN post_tags ON post_tags.tag_id = tags.id
+		GROUP BY tags.name
+		ORDER BY COUNT(*) DESC, tags.name;
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tags []newsStorage.Tag
+	for rows.Next() {
+		var t newsStorage.Tag
+		err = rows.Scan(
+			&t.Name,
+			&t.Count,
+		)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+// ClusterPosts returns the publications of the stories without their content
+// and media, in the order of addition.
+func (s *Storage) ClusterPosts(clusters []int) ([]newsStorage.Post, error) {
+	if len(clusters) == 0 {
+		return nil, nil
+	}
+	var args []interface{}
+	var ids []string
+	for _, c := range clusters {
+		args = append(args, c)
+		ids = append(ids, fmt.Sprintf("?%d", len(args)))
+	}
+	rows, err := s.db.Query(`
+		SELECT 
+			id,
+			title,
+			published_at,
+			pubtime_estimated,
+			link,
+			COALESCE(source_id, 0),
+			source_title,
+			author,
+			guid,
+			cluster_id
+		FROM posts
+		WHERE cluster_id IN (`+strings.Join(ids, ", ")+`)
+		ORDER BY id;
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var posts []newsStorage.Post
+	for rows.Next() {
+		var p newsStorage.Post
+		err = rows.Scan(
+			&p.ID,
+			&p.Title,
+			&p.PubTime,
+			&p.PubTimeEstimated,
+			&p.Link,
+			&p.SourceID,
+			&p.SourceTitle,
+			&p.Author,
+			&p.GUID,
+			&p.ClusterID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, p)
+	}
+	return posts, rows.Err()
+}
+
+// clusterIndex returns the index of the stored publications which the new
+// publications can be near-duplicates of. Publications inserted by the
+// transaction have no cluster yet and are left out.
+func clusterIndex(tx *sql.Tx, posts []dedup.Entry) (*dedup.Index, error) {
+	from, to := posts[0].PubTime, posts[0].PubTime
+	for _, p := range posts {
+		if p.PubTime < from {
+			from = p.PubTime
+		}
+		if p.PubTime > to {
+			to = p.PubTime
+		}
+	}
+	rows, err := tx.Query(`
+		SELECT 
+			id,
+			cluster_id,
+			simhash,
+			published_at
+		FROM posts
+		WHERE simhash <> 0 AND cluster_id <> 0 AND published_at BETWEEN ?1 AND ?2;
+	`, from-dedup.Window, to+dedup.Window)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var entries []dedup.Entry
+	for rows.Next() {
+		var e dedup.Entry
+		var fp int64
+		err = rows.Scan(
+			&e.ID,
+			&e.Cluster,
+			&fp,
+			&e.PubTime,
+		)
+		if err != nil {
+			return nil, err
+		}
+		e.Fingerprint = uint64(fp)
+		entries = append(entries, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return dedup.NewIndex(entries), nil
+}
+
+// AddPosts inserts new publications and updates the edited ones in a single
+// transaction. Publications are matched by newsStorage.Key. A publication
+// without an article keeps the stored one. New publications join the story
+// of their near-duplicates, edited ones stay in their story.
+func (s *Storage) AddPosts(posts []newsStorage.Post) (newsStorage.AddResult, error) {
+	var res newsStorage.AddResult
+	if len(posts) == 0 {
+		return res, nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return res, err
+	}
+	defer tx.Rollback()
+
+	var added []dedup.Entry // inserted publications to cluster
+	for _, post := range posts {
+		fp := dedup.Fingerprint(post.Title, post.Content)
+		var id int
+		var title, contentHTML, article string
+		err = tx.QueryRow(`
+			SELECT id, title, content_html, article
+			FROM posts
+			WHERE dedup_key = ?1`,
+			newsStorage.Key(post),
+		).Scan(&id, &title, &contentHTML, &article)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			var r sql.Result
+			r, err = tx.Exec(`
+			INSERT INTO posts(title, content, content_html, article, published_at, pubtime_estimated, link, source_id, source_title, author, guid, dedup_key, simhash)
+			VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, NULLIF(?8, 0), ?9, ?10, ?11, ?12, ?13)`,
+				post.Title,
+				post.Content,
+				post.ContentHTML,
+				post.Article,
+				post.PubTime,
+				post.PubTimeEstimated,
+				post.Link,
+				post.SourceID,
+				post.SourceTitle,
+				post.Author,
+				post.GUID,
+				newsStorage.Key(post),
+				int64(fp),
+			)
+			if err != nil {
+				return newsStorage.AddResult{}, err
+			}
+			var lastID int64
+			lastID, err = r.LastInsertId()
+			if err != nil {
+				return newsStorage.AddResult{}, err
+			}
+			id = int(lastID)
+			added = append(added, dedup.Entry{ID: id, Fingerprint: fp, PubTime: post.PubTime})
+			res.Inserted++
+		case err != nil:
+			return newsStorage.AddResult{}, err
+		case title == post.Title && contentHTML == post.ContentHTML && (post.Article == "" || article == post.Article):
+			res.Skipped++
+			continue
+		default:
+			_, err = tx.Exec(`
+			UPDATE posts
+			SET title = ?2, content = ?3, content_html = ?4, article = COALESCE(NULLIF(?5, ''), article),
+				author = ?6, link = ?7, simhash = ?8
+			WHERE id = ?1`,
+				id,
+				post.Title,
+				post.Content,
+				post.ContentHTML,
+				post.Article,
+				post.Author,
+				post.Link,
+				int64(fp),
+			)
+			if err != nil {
+				return newsStorage.AddResult{}, err
+			}
+			res.Updated++
+		}
+		// Tags and media of the inserted and updated publications are replaced.
+		err = replaceRelations(tx, id, post)
+		if err != nil {
+			return newsStorage.AddResult{}, err
+		}
+	}
+	// The stored publications are loaded only if there are new ones to
+	// cluster.
+	if len(added) > 0 {
+		index, err := clusterIndex(tx, added)
+		if err != nil {
+			return newsStorage.AddResult{}, err
+		}
+		for _, e := range added {
+			_, err = tx.Exec(`UPDATE posts SET cluster_id = ?2 WHERE id = ?1`, e.ID, index.Add(e.ID, e.Fingerprint, e.PubTime))
+			if err != nil {
+				return newsStorage.AddResult{}, err
+			}
+		}
+	}
+	return res, tx.Commit()
+}
+
+// replaceRelations replaces the tags and media of the publication.
+func replaceRelations(tx *sql.Tx, id int, post newsStorage.Post) error {
+	_, err := tx.Exec(`DELETE FROM post_tags WHERE post_id = ?1`, id)
+	if err != nil {
+		return err
+	}
+	for _, tag := range newsStorage.NormalizeTags(post.Tags) {
+		_, err = tx.Exec(`
+			INSERT INTO tags(name)
+			VALUES (?1)
+			ON CONFLICT (name) DO NOTHING`,
+			tag,
+		)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`
+			INSERT INTO post_tags(post_id, tag_id)
+			SELECT ?1, id FROM tags WHERE name = ?2
+			ON CONFLICT DO NOTHING`,
+			id,
+			tag,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec(`DELETE FROM post_media WHERE post_id = ?1`, id)
+	if err != nil {
+		return err
+	}
+	for _, m := range post.Media {
+		_, err = tx.Exec(`
+			INSERT INTO post_media(post_id, url, type, medium, width, height, length)
+			VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7)`,
+			id,
+			m.URL,
+			m.Type,
+			m.Medium,
+			m.Width,
+			m.Height,
+			m.Length,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
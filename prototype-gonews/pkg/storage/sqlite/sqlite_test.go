@@ -0,0 +1,87 @@
+package sqlite
+
+import (
+	newsStorage "GoNews/news/pkg/storage"
+	"GoNews/news/pkg/storage/storagetest"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.News(t, func(t *testing.T) newsStorage.NewsInterface {
+		s, err := New(":memory:")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { s.Close() })
+		return s
+	})
+}
+
+// TestMigrateRoundTrip reverts the migrations over publications which only
+// the later schema allows and applies them again.
+func TestMigrateRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "news.db")
+	s, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	var sources []int
+	for _, url := range []string{"https://a.example.com/rss", "https://b.example.com/rss"} {
+		id, err := s.AddFeed(newsStorage.Feed{URL: url, Interval: 5})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sources = append(sources, id)
+	}
+	// One GUID in two feeds.
+	_, err = s.AddPosts([]newsStorage.Post{
+		{Title: "Harbor", Content: "Harbor bridge closes", PubTime: 300, Link: "https://a.example.com/harbor", GUID: "1", SourceID: sources[0]},
+		{Title: "Bakery", Content: "Bakery wins award", PubTime: 400, Link: "https://b.example.com/bakery", GUID: "1", SourceID: sources[1]},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := Migrator(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	// The raw GUID key is restored for one of the publications sharing it.
+	_, err = m.Down(1)
+	if err != nil {
+		t.Fatalf("Down(1) error = %v", err)
+	}
+	keys, err := s.db.Query(`SELECT dedup_key FROM posts ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for keys.Next() {
+		var key string
+		if err := keys.Scan(&key); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, key)
+	}
+	keys.Close()
+	if want := []string{"1", "guid:" + strconv.Itoa(sources[1]) + ":1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("keys = %q, want %q", got, want)
+	}
+
+	_, err = m.Up(0)
+	if err != nil {
+		t.Fatalf("Up() again error = %v", err)
+	}
+	posts, _, err := s.Posts(newsStorage.Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 2 {
+		t.Errorf("Posts() = %d publications, want 2", len(posts))
+	}
+}
@@ -1,23 +1,122 @@
 package newsStorage
 
+import "errors"
+
+// ErrNotFound is returned when the requested record does not exist.
+var ErrNotFound = errors.New("record not found")
+
 // Publication retrieved from RSS.
 type Post struct {
-	ID      int    // record number
-	Title   string // publication title
-	Content string // publication content
-	PubTime int64  // publication time
-	Link    string // publication link
+	ID               int      // record number
+	Title            string   // publication title
+	Content          string   // publication content as plain text
+	ContentHTML      string   // publication content as sanitized HTML
+	Article          string   // full article text extracted from the publication page
+	PubTime          int64    // publication time
+	PubTimeEstimated bool     // the feed gave no valid date, PubTime is the fetch time
+	Link             string   // publication link
+	SourceID         int      // identifier of the source feed, 0 if unknown
+	SourceTitle      string   // title of the source feed
+	Author           string   // publication author
+	GUID             string   // identifier of the publication within the feed
+	ClusterID        int      // identifier of the first publication of the story
+	Media            []Media  // media files attached to the publication
+	Tags             []string // normalized categories of the publication
+	Snippet          string   // content fragments matching the search query, HTML with the matches in <mark>
+}
+
+// Tag with the number of publications marked with it.
+type Tag struct {
+	Name  string // normalized tag name
+	Count int    // number of publications
+}
+
+// Media file attached to a publication.
+type Media struct {
+	URL    string // file address
+	Type   string // MIME type
+	Medium string // image, video or audio
+	Width  int    // width in pixels, 0 if unknown
+	Height int    // height in pixels, 0 if unknown
+	Length int64  // size in bytes, 0 if unknown
+}
+
+// Result of adding a batch of publications.
+type AddResult struct {
+	Inserted int // new publications
+	Updated  int // publications edited by the publisher
+	Skipped  int // publications already stored without changes
+}
+
+// Sort orders of publications.
+const (
+	SortID        = "id"        // order of addition
+	SortPubTime   = "pubtime"   // publication time
+	SortRelevance = "relevance" // rank of the search query match
+)
+
+// Bounds of the number of publications on a page.
+const (
+	DefaultPageSize = 15
+	MaxPageSize     = 100
+)
+
+// Query selects a page of publications. Zero fields do not restrict the
+// selection.
+type Query struct {
+	Page      int      // page number starting from 1, ignored with a cursor
+	Cursor    *Cursor  // position to continue from in the order of the cursor
+	PageSize  int      // number of publications on a page, see Limit for the bounds
+	Search    string   // full-text search query over the title and content
+	From      int64    // earliest publication time
+	To        int64    // latest publication time
+	SourceIDs []int    // publications of any of the source feeds
+	Tags      []string // publications marked with any of the tags
+	Sort      string   // sort order, see SortOrder for the defaults
+	Asc       bool     // ascending order instead of the default descending one
 }
 
 type Pagination struct {
-    TotalPages   int
-    CurrentPage  int
-    PageSize     int
+	TotalPages  int
+	CurrentPage int // 0 for a page selected by a cursor
+	PageSize    int
+	TotalItems  int    // number of publications selected by the query
+	NextCursor  string // cursor of the next page, empty on the last one
+	PrevCursor  string // cursor of the previous page, empty on the first one
+}
+
+// News source polled by the service.
+type Feed struct {
+	ID       int    // record number
+	URL      string // feed address
+	Title    string // feed title
+	Category string // folder the feed is grouped into, nested folders are separated by "/"
+	Paused   bool   // polling is suspended
+	Interval int    // poll interval in minutes, 0 means the default period
+	FullText bool   // publications are truncated, the full article is downloaded from the link
+
+	// HTTP cache validators of the last response whose publications were
+	// saved, sent with the next poll.
+	ETag         string `json:"-"`
+	LastModified string `json:"-"`
 }
 
 // NewsInterface specifies the contract for working with the database.
 type NewsInterface interface {
-	Posts(int, string) ([]Post, Pagination, error) // Get publications from the database.
-	AddPosts([]Post) error     // Add publications to the database.
-	PostDetail(int) (*Post, error) // Get detailed publication
+	Posts(Query) ([]Post, Pagination, error) // Get a page of publications selected by the query, one per story.
+	AddPosts([]Post) (AddResult, error)      // Add or update publications in the database, grouping them into stories.
+	PostDetail(int) (*Post, error)           // Get detailed publication
+	Tags() ([]Tag, error)                    // Get tags with publication counts.
+	ClusterPosts([]int) ([]Post, error)      // Get publications of the stories without content.
+}
+
+// FeedsInterface specifies the contract for managing news sources.
+type FeedsInterface interface {
+	Feeds() ([]Feed, error)    // Get all news sources.
+	Feed(int) (*Feed, error)   // Get a news source by its identifier.
+	AddFeed(Feed) (int, error) // Add a news source and return its identifier.
+	UpdateFeed(Feed) error     // Update a news source.
+	DeleteFeed(int) error      // Delete a news source.
+
+	SetFeedValidators(id int, etag, lastModified string) error // Store the validators of a saved response.
 }
@@ -0,0 +1,734 @@
+// Package storagetest is a conformance suite for the implementations of
+// newsStorage.NewsInterface. A backend runs it from its own tests:
+//
+//	func TestConformance(t *testing.T) {
+//		storagetest.News(t, func(t *testing.T) newsStorage.NewsInterface {
+//			return memdb.New()
+//		})
+//	}
+package storagetest
+
+import (
+	newsStorage "GoNews/news/pkg/storage"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Opener returns an empty storage for a single test.
+type Opener func(t *testing.T) newsStorage.NewsInterface
+
+// texts are unrelated publications, so none of them is a near-duplicate of
+// another one.
+var texts = []struct{ title, content string }{
+	{"Harbor bridge closes for repairs", "Engineers found cracked cables during the spring inspection and shut both lanes until autumn."},
+	{"Local bakery wins national award", "Judges praised the sourdough loaves baked by a family that opened its shop forty years ago."},
+	{"Storm warning issued for coast", "Forecasters expect gusts above ninety kilometres per hour and advise fishermen to stay ashore."},
+	{"University opens quantum laboratory", "Researchers will cool superconducting circuits close to absolute zero in the new building."},
+	{"City marathon breaks attendance record", "Twelve thousand runners crossed the finish line while crowds cheered along the river embankment."},
+	{"Museum returns stolen painting", "Detectives traced the canvas through three auction houses before identifying the rightful owners."},
+}
+
+// News runs the conformance tests against the storages returned by open.
+func News(t *testing.T, open Opener) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, db newsStorage.NewsInterface)
+	}{
+		{"Empty", testEmpty},
+		{"MissingID", testMissingID},
+		{"Detail", testDetail},
+		{"Duplicates", testDuplicates},
+		{"SharedGUID", testSharedGUID},
+		{"Ordering", testOrdering},
+		{"Pages", testPages},
+		{"Cursors", testCursors},
+		{"CursorsWhileAdding", testCursorsWhileAdding},
+		{"Search", testSearch},
+		{"Filters", testFilters},
+		{"Sources", testSources},
+		{"Validators", testValidators},
+		{"FeedExists", testFeedExists},
+		{"Stories", testStories},
+		{"Tags", testTags},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, open(t))
+		})
+	}
+}
+
+// post returns the i-th test publication published at pubTime.
+func post(i int, pubTime int64) newsStorage.Post {
+	text := texts[i%len(texts)]
+	return newsStorage.Post{
+		Title:       text.title,
+		Content:     text.content,
+		ContentHTML: "<p>" + text.content + "</p>",
+		PubTime:     pubTime,
+		Link:        fmt.Sprintf("https://example.com/news/%d", i),
+	}
+}
+
+// add stores the publications and fails the test on an error.
+func add(t *testing.T, db newsStorage.NewsInterface, posts ...newsStorage.Post) newsStorage.AddResult {
+	t.Helper()
+	res, err := db.AddPosts(posts)
+	if err != nil {
+		t.Fatalf("AddPosts() error = %v", err)
+	}
+	return res
+}
+
+// list returns the publications selected by the query and fails the test on
+// an error.
+func list(t *testing.T, db newsStorage.NewsInterface, q newsStorage.Query) ([]newsStorage.Post, newsStorage.Pagination) {
+	t.Helper()
+	posts, p, err := db.Posts(q)
+	if err != nil {
+		t.Fatalf("Posts(%+v) error = %v", q, err)
+	}
+	return posts, p
+}
+
+// ids returns the identifiers of the publications.
+func ids(posts []newsStorage.Post) []int {
+	ids := []int{}
+	for _, p := range posts {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+// titles returns the titles of the publications.
+func titles(posts []newsStorage.Post) []string {
+	titles := []string{}
+	for _, p := range posts {
+		titles = append(titles, p.Title)
+	}
+	return titles
+}
+
+// addN stores the first n test publications, the i-th one published at
+// 1000+i, and returns them in the order of addition.
+func addN(t *testing.T, db newsStorage.NewsInterface, n int) []newsStorage.Post {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		add(t, db, post(i, int64(1000+i)))
+	}
+	posts, _ := list(t, db, newsStorage.Query{PageSize: newsStorage.MaxPageSize, Sort: newsStorage.SortID, Asc: true})
+	if len(posts) != n {
+		t.Fatalf("Posts() returned %d publications, want %d", len(posts), n)
+	}
+	return posts
+}
+
+func testEmpty(t *testing.T, db newsStorage.NewsInterface) {
+	posts, p := list(t, db, newsStorage.Query{})
+	if len(posts) != 0 {
+		t.Errorf("Posts() returned %d publications, want 0", len(posts))
+	}
+	if p.TotalItems != 0 || p.TotalPages != 0 {
+		t.Errorf("Pagination = %+v, want no items and pages", p)
+	}
+	if p.NextCursor != "" || p.PrevCursor != "" {
+		t.Errorf("Pagination = %+v, want no cursors", p)
+	}
+	res := add(t, db)
+	if res != (newsStorage.AddResult{}) {
+		t.Errorf("AddPosts(nil) = %+v, want nothing added", res)
+	}
+	tags, err := db.Tags()
+	if err != nil {
+		t.Fatalf("Tags() error = %v", err)
+	}
+	if len(tags) != 0 {
+		t.Errorf("Tags() = %v, want none", tags)
+	}
+	cluster, err := db.ClusterPosts([]int{1})
+	if err != nil {
+		t.Fatalf("ClusterPosts() error = %v", err)
+	}
+	if len(cluster) != 0 {
+		t.Errorf("ClusterPosts() returned %d publications, want 0", len(cluster))
+	}
+}
+
+func testMissingID(t *testing.T, db newsStorage.NewsInterface) {
+	posts := addN(t, db, 1)
+	for _, id := range []int{posts[0].ID + 1, -1, 1 << 30} {
+		p, err := db.PostDetail(id)
+		if !errors.Is(err, newsStorage.ErrNotFound) {
+			t.Errorf("PostDetail(%d) = %v, %v; want ErrNotFound", id, p, err)
+		}
+	}
+}
+
+func testDetail(t *testing.T, db newsStorage.NewsInterface) {
+	want := post(0, 1000)
+	want.Article = "The full text of the article."
+	want.PubTimeEstimated = true
+	want.SourceTitle = "Example News"
+	want.Author = "Jane Doe"
+	want.GUID = "urn:example:1"
+	want.Tags = []string{"Transport", " city  news ", "transport"}
+	want.Media = []newsStorage.Media{
+		{URL: "https://example.com/1.jpg", Type: "image/jpeg", Medium: "image", Width: 640, Height: 480},
+		{URL: "https://example.com/1.mp3", Type: "audio/mpeg", Medium: "audio", Length: 1024},
+	}
+	add(t, db, want)
+	posts, _ := list(t, db, newsStorage.Query{})
+	if len(posts) != 1 {
+		t.Fatalf("Posts() returned %d publications, want 1", len(posts))
+	}
+	got, err := db.PostDetail(posts[0].ID)
+	if err != nil {
+		t.Fatalf("PostDetail() error = %v", err)
+	}
+	want.ID = posts[0].ID
+	want.ClusterID = posts[0].ID
+	want.Tags = []string{"city news", "transport"}
+	if !reflect.DeepEqual(*got, want) {
+		t.Errorf("PostDetail() = %+v, want %+v", *got, want)
+	}
+}
+
+func testDuplicates(t *testing.T, db newsStorage.NewsInterface) {
+	p := post(0, 1000)
+	p.Article = "The full text of the article."
+	if res := add(t, db, p, p); res != (newsStorage.AddResult{Inserted: 1, Skipped: 1}) {
+		t.Errorf("AddPosts() of a repeated publication = %+v, want 1 inserted and 1 skipped", res)
+	}
+	if res := add(t, db, p); res != (newsStorage.AddResult{Skipped: 1}) {
+		t.Errorf("AddPosts() of a stored publication = %+v, want 1 skipped", res)
+	}
+	// Equivalent spellings of the link identify the same publication.
+	same := p
+	same.Link = "HTTPS://Example.com:443/news/0/?utm_source=feed#comments"
+	if res := add(t, db, same); res != (newsStorage.AddResult{Skipped: 1}) {
+		t.Errorf("AddPosts() of a normalized link = %+v, want 1 skipped", res)
+	}
+	// An edited publication without the article keeps the stored one.
+	edited := p
+	edited.Title = "Harbor bridge reopens after repairs"
+	edited.Article = ""
+	if res := add(t, db, edited); res != (newsStorage.AddResult{Updated: 1}) {
+		t.Errorf("AddPosts() of an edited publication = %+v, want 1 updated", res)
+	}
+	posts, _ := list(t, db, newsStorage.Query{})
+	if len(posts) != 1 {
+		t.Fatalf("Posts() returned %d publications, want 1", len(posts))
+	}
+	got, err := db.PostDetail(posts[0].ID)
+	if err != nil {
+		t.Fatalf("PostDetail() error = %v", err)
+	}
+	if got.Title != edited.Title {
+		t.Errorf("Title = %q, want %q", got.Title, edited.Title)
+	}
+	if got.Article != p.Article {
+		t.Errorf("Article = %q, want %q", got.Article, p.Article)
+	}
+
+	// The GUID identifies a publication whatever its link is.
+	a := post(1, 1000)
+	a.GUID = "urn:example:guid"
+	b := a
+	b.Link = "https://mirror.example.com/bakery"
+	if res := add(t, db, a, b); res != (newsStorage.AddResult{Inserted: 1, Skipped: 1}) {
+		t.Errorf("AddPosts() of a repeated GUID = %+v, want 1 inserted and 1 skipped", res)
+	}
+	if _, p := list(t, db, newsStorage.Query{}); p.TotalItems != 2 {
+		t.Errorf("TotalItems = %d, want 2", p.TotalItems)
+	}
+}
+
+func testSharedGUID(t *testing.T, db newsStorage.NewsInterface) {
+	// A GUID is unique within its feed only.
+	feeds, ok := db.(newsStorage.FeedsInterface)
+	if !ok {
+		t.Skip("the storage has no news sources")
+	}
+	var posts []newsStorage.Post
+	for i, url := range []string{"https://a.example.com/rss", "https://b.example.com/rss"} {
+		id, err := feeds.AddFeed(newsStorage.Feed{URL: url})
+		if err != nil {
+			t.Fatalf("AddFeed() error = %v", err)
+		}
+		p := post(i, 1000)
+		p.SourceID = id
+		p.GUID = "1"
+		posts = append(posts, p)
+	}
+	if res := add(t, db, posts...); res != (newsStorage.AddResult{Inserted: 2}) {
+		t.Errorf("AddPosts() of feeds sharing a GUID = %+v, want 2 inserted", res)
+	}
+	if res := add(t, db, posts[1]); res != (newsStorage.AddResult{Skipped: 1}) {
+		t.Errorf("AddPosts() of a stored publication = %+v, want 1 skipped", res)
+	}
+	got, _ := list(t, db, newsStorage.Query{})
+	want := []string{posts[1].Title, posts[0].Title}
+	if !reflect.DeepEqual(titles(got), want) {
+		t.Errorf("Posts() = %q, want %q", titles(got), want)
+	}
+}
+
+func testOrdering(t *testing.T, db newsStorage.NewsInterface) {
+	// Publication times are out of the order of addition, two of them equal.
+	for i, pubTime := range []int64{3000, 1000, 2000, 2000} {
+		add(t, db, post(i, pubTime))
+	}
+	byID, _ := list(t, db, newsStorage.Query{Sort: newsStorage.SortID, Asc: true})
+	id := ids(byID)
+	tests := []struct {
+		name string
+		q    newsStorage.Query
+		want []int
+	}{
+		{"default is newest first", newsStorage.Query{}, []int{id[3], id[2], id[1], id[0]}},
+		{"id ascending", newsStorage.Query{Sort: newsStorage.SortID, Asc: true}, id},
+		{"pubtime descending", newsStorage.Query{Sort: newsStorage.SortPubTime}, []int{id[0], id[3], id[2], id[1]}},
+		{"pubtime ascending", newsStorage.Query{Sort: newsStorage.SortPubTime, Asc: true}, []int{id[1], id[2], id[3], id[0]}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			posts, _ := list(t, db, tt.q)
+			if got := ids(posts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Posts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func testPages(t *testing.T, db newsStorage.NewsInterface) {
+	all := addN(t, db, 5)
+	id := ids(all)
+	tests := []struct {
+		name  string
+		q     newsStorage.Query
+		want  []int
+		pages newsStorage.Pagination
+	}{
+		{
+			name:  "first page",
+			q:     newsStorage.Query{PageSize: 2},
+			want:  []int{id[4], id[3]},
+			pages: newsStorage.Pagination{TotalPages: 3, CurrentPage: 1, PageSize: 2, TotalItems: 5},
+		},
+		{
+			name:  "last partial page",
+			q:     newsStorage.Query{Page: 3, PageSize: 2},
+			want:  []int{id[0]},
+			pages: newsStorage.Pagination{TotalPages: 3, CurrentPage: 3, PageSize: 2, TotalItems: 5},
+		},
+		{
+			name:  "page past the end",
+			q:     newsStorage.Query{Page: 10, PageSize: 2},
+			want:  []int{},
+			pages: newsStorage.Pagination{TotalPages: 3, CurrentPage: 10, PageSize: 2, TotalItems: 5},
+		},
+		{
+			name:  "negative page is the first one",
+			q:     newsStorage.Query{Page: -1, PageSize: 2},
+			want:  []int{id[4], id[3]},
+			pages: newsStorage.Pagination{TotalPages: 3, CurrentPage: 1, PageSize: 2, TotalItems: 5},
+		},
+		{
+			name:  "default page size",
+			q:     newsStorage.Query{},
+			want:  []int{id[4], id[3], id[2], id[1], id[0]},
+			pages: newsStorage.Pagination{TotalPages: 1, CurrentPage: 1, PageSize: newsStorage.DefaultPageSize, TotalItems: 5},
+		},
+		{
+			name:  "page size over the maximum",
+			q:     newsStorage.Query{PageSize: newsStorage.MaxPageSize + 1},
+			want:  []int{id[4], id[3], id[2], id[1], id[0]},
+			pages: newsStorage.Pagination{TotalPages: 1, CurrentPage: 1, PageSize: newsStorage.MaxPageSize, TotalItems: 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			posts, p := list(t, db, tt.q)
+			if got := ids(posts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Posts() = %v, want %v", got, tt.want)
+			}
+			// Page cursors are checked by testCursors.
+			p.NextCursor, p.PrevCursor = "", ""
+			if p != tt.pages {
+				t.Errorf("Pagination = %+v, want %+v", p, tt.pages)
+			}
+		})
+	}
+}
+
+func testCursors(t *testing.T, db newsStorage.NewsInterface) {
+	// Two publications share the time, so the identifier breaks the tie. The
+	// order of addition differs from the publication time order.
+	for i, pubTime := range []int64{2000, 1000, 3000, 2000, 4000} {
+		add(t, db, post(i, pubTime))
+	}
+	for _, tt := range []struct {
+		sort string
+		asc  bool
+	}{
+		{newsStorage.SortPubTime, false},
+		{newsStorage.SortPubTime, true},
+		{newsStorage.SortID, false},
+		{newsStorage.SortID, true},
+	} {
+		sort, asc := tt.sort, tt.asc
+		t.Run(fmt.Sprintf("sort=%s,asc=%v", sort, asc), func(t *testing.T) {
+			all, _ := list(t, db, newsStorage.Query{Sort: sort, Asc: asc})
+			want := ids(all)
+
+			// Forward through the pages from the first one.
+			q := newsStorage.Query{Sort: sort, Asc: asc, PageSize: 2}
+			posts, p := list(t, db, q)
+			if p.PrevCursor != "" {
+				t.Errorf("first page PrevCursor = %q, want none", p.PrevCursor)
+			}
+			pages := [][]int{ids(posts)}
+			for p.NextCursor != "" && len(pages) <= len(want) {
+				c, err := newsStorage.ParseCursor(p.NextCursor)
+				if err != nil {
+					t.Fatalf("ParseCursor(%q) error = %v", p.NextCursor, err)
+				}
+				posts, p = list(t, db, newsStorage.Query{Asc: asc, PageSize: 2, Cursor: &c})
+				if p.CurrentPage != 0 {
+					t.Errorf("CurrentPage = %d, want 0 for a cursor", p.CurrentPage)
+				}
+				if p.PrevCursor == "" {
+					t.Errorf("page after a cursor has no PrevCursor")
+				}
+				pages = append(pages, ids(posts))
+			}
+			var got []int
+			for _, page := range pages {
+				got = append(got, page...)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("pages forward = %v, want %v", pages, want)
+			}
+
+			// Backward from the last page.
+			for i := len(pages) - 2; i >= 0; i-- {
+				if p.PrevCursor == "" {
+					t.Fatalf("page %d has no PrevCursor", i+2)
+				}
+				c, err := newsStorage.ParseCursor(p.PrevCursor)
+				if err != nil {
+					t.Fatalf("ParseCursor(%q) error = %v", p.PrevCursor, err)
+				}
+				posts, p = list(t, db, newsStorage.Query{Asc: asc, PageSize: 2, Cursor: &c})
+				if got := ids(posts); !reflect.DeepEqual(got, pages[i]) {
+					t.Errorf("page %d backward = %v, want %v", i+1, got, pages[i])
+				}
+				if p.NextCursor == "" {
+					t.Errorf("page %d backward has no NextCursor", i+1)
+				}
+			}
+			if p.PrevCursor != "" {
+				t.Errorf("first page backward PrevCursor = %q, want none", p.PrevCursor)
+			}
+		})
+	}
+}
+
+func testCursorsWhileAdding(t *testing.T, db newsStorage.NewsInterface) {
+	for i := 0; i < 5; i++ {
+		add(t, db, post(i, int64(1000+i)))
+	}
+	all, _ := list(t, db, newsStorage.Query{})
+	want := ids(all)
+
+	// Publications added between the pages of the default listing go before
+	// the first page and do not shift the following ones.
+	posts, p := list(t, db, newsStorage.Query{PageSize: 2})
+	got := ids(posts)
+	for i := 5; p.NextCursor != "" && len(got) <= len(want); i++ {
+		add(t, db, post(i, int64(1000+i)))
+		c, err := newsStorage.ParseCursor(p.NextCursor)
+		if err != nil {
+			t.Fatalf("ParseCursor(%q) error = %v", p.NextCursor, err)
+		}
+		posts, p = list(t, db, newsStorage.Query{PageSize: 2, Cursor: &c})
+		got = append(got, ids(posts)...)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("pages = %v, want %v", got, want)
+	}
+}
+
+func testSearch(t *testing.T, db newsStorage.NewsInterface) {
+	weather := post(2, 1000)
+	weather.Title = "Weather update"
+	weather.Content = "A storm may reach the harbor tonight."
+	storm := post(3, 2000)
+	storm.Title = "Storm hits the coast"
+	storm.Content = "The storm flooded streets, and the storm surge damaged boats in the harbor."
+	add(t, db, weather, storm, post(1, 3000), post(4, 4000))
+
+	tests := []struct {
+		name   string
+		search string
+		want   []string
+	}{
+		{"most relevant first", "storm", []string{storm.Title, weather.Title}},
+		{"case-insensitive", "STORM", []string{storm.Title, weather.Title}},
+		{"every word", "storm boats", []string{storm.Title}},
+		{"no match", "volcano", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			posts, p := list(t, db, newsStorage.Query{Search: tt.search})
+			if got := titles(posts); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Posts(%q) = %q, want %q", tt.search, got, tt.want)
+			}
+			if p.TotalItems != len(tt.want) {
+				t.Errorf("TotalItems = %d, want %d", p.TotalItems, len(tt.want))
+			}
+			for _, post := range posts {
+				if !strings.Contains(post.Snippet, "<mark>") {
+					t.Errorf("Snippet = %q, want highlighted words", post.Snippet)
+				}
+			}
+		})
+	}
+
+	// Sorting by time overrides the relevance.
+	posts, _ := list(t, db, newsStorage.Query{Search: "storm", Sort: newsStorage.SortPubTime, Asc: true})
+	if got, want := titles(posts), []string{weather.Title, storm.Title}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Posts() by time = %q, want %q", got, want)
+	}
+	// Publications out of the search have no snippet.
+	posts, _ = list(t, db, newsStorage.Query{})
+	for _, post := range posts {
+		if post.Snippet != "" {
+			t.Errorf("Snippet = %q without a search, want none", post.Snippet)
+		}
+	}
+}
+
+func testFilters(t *testing.T, db newsStorage.NewsInterface) {
+	var posts []newsStorage.Post
+	for i, tags := range [][]string{{"sport"}, {"culture", "city"}, {"weather"}, {"Sport", "city"}} {
+		p := post(i, int64(1000*(i+1)))
+		p.Tags = tags
+		posts = append(posts, p)
+	}
+	add(t, db, posts...)
+	tests := []struct {
+		name string
+		q    newsStorage.Query
+		want []string
+	}{
+		{"from", newsStorage.Query{From: 2000}, []string{posts[3].Title, posts[2].Title, posts[1].Title}},
+		{"to", newsStorage.Query{To: 2000}, []string{posts[1].Title, posts[0].Title}},
+		{"from and to", newsStorage.Query{From: 2000, To: 3000}, []string{posts[2].Title, posts[1].Title}},
+		{"tag", newsStorage.Query{Tags: []string{"SPORT"}}, []string{posts[3].Title, posts[0].Title}},
+		{"any of tags", newsStorage.Query{Tags: []string{"weather", "culture"}}, []string{posts[2].Title, posts[1].Title}},
+		{"tag and time", newsStorage.Query{Tags: []string{"city"}, To: 3000}, []string{posts[1].Title}},
+		{"unknown tag", newsStorage.Query{Tags: []string{"politics"}}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, p := list(t, db, tt.q)
+			if !reflect.DeepEqual(titles(got), tt.want) {
+				t.Errorf("Posts(%+v) = %q, want %q", tt.q, titles(got), tt.want)
+			}
+			if p.TotalItems != len(tt.want) {
+				t.Errorf("TotalItems = %d, want %d", p.TotalItems, len(tt.want))
+			}
+		})
+	}
+}
+
+func testSources(t *testing.T, db newsStorage.NewsInterface) {
+	// Publications refer to the news sources of the storage.
+	feeds, ok := db.(newsStorage.FeedsInterface)
+	if !ok {
+		t.Skip("the storage has no news sources")
+	}
+	var sources []int
+	for _, url := range []string{"https://a.example.com/rss", "https://b.example.com/rss"} {
+		id, err := feeds.AddFeed(newsStorage.Feed{URL: url})
+		if err != nil {
+			t.Fatalf("AddFeed() error = %v", err)
+		}
+		sources = append(sources, id)
+	}
+	var posts []newsStorage.Post
+	for i, source := range []int{sources[0], sources[1], sources[0], 0} {
+		p := post(i, int64(1000*(i+1)))
+		p.SourceID = source
+		posts = append(posts, p)
+	}
+	add(t, db, posts...)
+	tests := []struct {
+		name    string
+		sources []int
+		want    []string
+	}{
+		{"one", sources[:1], []string{posts[2].Title, posts[0].Title}},
+		{"several", sources, []string{posts[2].Title, posts[1].Title, posts[0].Title}},
+		{"unknown", []int{sources[1] + 100}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := list(t, db, newsStorage.Query{SourceIDs: tt.sources})
+			if !reflect.DeepEqual(titles(got), tt.want) {
+				t.Errorf("Posts(%v) = %q, want %q", tt.sources, titles(got), tt.want)
+			}
+		})
+	}
+}
+
+func testValidators(t *testing.T, db newsStorage.NewsInterface) {
+	// The validators are kept until the address of the news source changes.
+	feeds, ok := db.(newsStorage.FeedsInterface)
+	if !ok {
+		t.Skip("the storage has no news sources")
+	}
+	id, err := feeds.AddFeed(newsStorage.Feed{URL: "https://a.example.com/rss"})
+	if err != nil {
+		t.Fatalf("AddFeed() error = %v", err)
+	}
+	err = feeds.SetFeedValidators(id, `"v1"`, "Mon, 04 Mar 2024 10:00:00 GMT")
+	if err != nil {
+		t.Fatalf("SetFeedValidators() error = %v", err)
+	}
+	validators := func() [2]string {
+		f, err := feeds.Feed(id)
+		if err != nil {
+			t.Fatalf("Feed() error = %v", err)
+		}
+		return [2]string{f.ETag, f.LastModified}
+	}
+	want := [2]string{`"v1"`, "Mon, 04 Mar 2024 10:00:00 GMT"}
+	if got := validators(); got != want {
+		t.Errorf("validators = %q, want %q", got, want)
+	}
+	err = feeds.UpdateFeed(newsStorage.Feed{ID: id, URL: "https://a.example.com/rss", Title: "A"})
+	if err != nil {
+		t.Fatalf("UpdateFeed() error = %v", err)
+	}
+	if got := validators(); got != want {
+		t.Errorf("validators after a new title = %q, want %q", got, want)
+	}
+	err = feeds.UpdateFeed(newsStorage.Feed{ID: id, URL: "https://b.example.com/rss"})
+	if err != nil {
+		t.Fatalf("UpdateFeed() error = %v", err)
+	}
+	if got := validators(); got != [2]string{} {
+		t.Errorf("validators after a new address = %q, want none", got)
+	}
+	err = feeds.SetFeedValidators(id+100, `"v2"`, "")
+	if !errors.Is(err, newsStorage.ErrNotFound) {
+		t.Errorf("SetFeedValidators(missing) error = %v, want %v", err, newsStorage.ErrNotFound)
+	}
+}
+
+func testFeedExists(t *testing.T, db newsStorage.NewsInterface) {
+	// A news source URL is stored once.
+	feeds, ok := db.(newsStorage.FeedsInterface)
+	if !ok {
+		t.Skip("the storage has no news sources")
+	}
+	_, err := feeds.AddFeed(newsStorage.Feed{URL: "https://a.example.com/rss"})
+	if err != nil {
+		t.Fatalf("AddFeed() error = %v", err)
+	}
+	id, err := feeds.AddFeed(newsStorage.Feed{URL: "https://b.example.com/rss"})
+	if err != nil {
+		t.Fatalf("AddFeed() error = %v", err)
+	}
+	_, err = feeds.AddFeed(newsStorage.Feed{URL: "https://a.example.com/rss"})
+	if !errors.Is(err, newsStorage.ErrFeedExists) {
+		t.Errorf("AddFeed(duplicate) error = %v, want %v", err, newsStorage.ErrFeedExists)
+	}
+	err = feeds.UpdateFeed(newsStorage.Feed{ID: id, URL: "https://a.example.com/rss"})
+	if !errors.Is(err, newsStorage.ErrFeedExists) {
+		t.Errorf("UpdateFeed(duplicate) error = %v, want %v", err, newsStorage.ErrFeedExists)
+	}
+}
+
+func testStories(t *testing.T, db newsStorage.NewsInterface) {
+	// The same story from another source a few hours later.
+	first := post(0, 1000)
+	reprint := first
+	reprint.Link = "https://other.example.com/bridge"
+	reprint.PubTime = first.PubTime + 3*60*60
+	// The same text outside the time window is another story.
+	late := first
+	late.Link = "https://late.example.com/bridge"
+	late.PubTime = first.PubTime + 30*24*60*60
+	add(t, db, first, post(1, 2000))
+	add(t, db, reprint, late)
+
+	posts, p := list(t, db, newsStorage.Query{Sort: newsStorage.SortID, Asc: true})
+	if len(posts) != 3 {
+		t.Fatalf("Posts() returned %d publications, want one per story: %q", len(posts), titles(posts))
+	}
+	if p.TotalItems != 3 {
+		t.Errorf("TotalItems = %d, want 3", p.TotalItems)
+	}
+	story := posts[0]
+	if story.Link != first.Link || story.ClusterID != story.ID {
+		t.Errorf("story = %+v, want the first publication heading its story", story)
+	}
+	if posts[2].ClusterID == story.ClusterID {
+		t.Errorf("publication out of the time window joined the story")
+	}
+
+	cluster, err := db.ClusterPosts([]int{story.ClusterID})
+	if err != nil {
+		t.Fatalf("ClusterPosts() error = %v", err)
+	}
+	if len(cluster) != 2 || cluster[0].ID != story.ID || cluster[1].Link != reprint.Link {
+		t.Fatalf("ClusterPosts() = %+v, want the publication and its reprint", cluster)
+	}
+	for _, p := range cluster {
+		if p.ClusterID != story.ClusterID || p.Content != "" {
+			t.Errorf("ClusterPosts() publication = %+v, want the story without content", p)
+		}
+	}
+	detail, err := db.PostDetail(cluster[1].ID)
+	if err != nil {
+		t.Fatalf("PostDetail() error = %v", err)
+	}
+	if detail.ClusterID != story.ClusterID {
+		t.Errorf("reprint ClusterID = %d, want %d", detail.ClusterID, story.ClusterID)
+	}
+
+	// Found publications of a story are collapsed as well.
+	posts, p = list(t, db, newsStorage.Query{Search: "bridge"})
+	if len(posts) != 2 || p.TotalItems != 2 {
+		t.Errorf("Posts(\"bridge\") = %q, %d items; want 2 stories", titles(posts), p.TotalItems)
+	}
+}
+
+func testTags(t *testing.T, db newsStorage.NewsInterface) {
+	var posts []newsStorage.Post
+	for i, tags := range [][]string{{"Sport", "city"}, {"culture", "city"}, {"city", "sport"}, nil} {
+		p := post(i, 1000)
+		p.Tags = tags
+		posts = append(posts, p)
+	}
+	add(t, db, posts...)
+	// Edited tags replace the stored ones.
+	posts[1].Title = "Local bakery wins another award"
+	posts[1].Tags = []string{"food", "city"}
+	add(t, db, posts[1])
+
+	tags, err := db.Tags()
+	if err != nil {
+		t.Fatalf("Tags() error = %v", err)
+	}
+	want := []newsStorage.Tag{{Name: "city", Count: 3}, {Name: "sport", Count: 2}, {Name: "food", Count: 1}}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("Tags() = %+v, want %+v", tags, want)
+	}
+}